@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aidansteele/ghal/logfmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const annotationsHeight = 8
+
+// annotationsMsg carries freshly fetched annotations for a run's job.
+type annotationsMsg struct {
+	runId       int64
+	jobName     string
+	annotations []*github.CheckRunAnnotation
+	err         error
+}
+
+var annotationsStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.NormalBorder()).
+	BorderTop(true)
+
+// fetchAnnotations loads the annotations of the job's check run, if their
+// number has changed since they were last loaded.
+func (m *model) fetchAnnotations(t *tailedRun, checkRun *github.CheckRun) tea.Cmd {
+	if checkRun == nil {
+		return nil
+	}
+
+	count := checkRun.GetOutput().GetAnnotationsCount()
+	if count == t.annotationsCount {
+		return nil
+	}
+	t.annotationsCount = count
+
+	ctx, ghl := m.ctx, m.ghl
+	run, jobName, id := runOf(t.wfRun), t.jobName, checkRun.GetID()
+	return func() tea.Msg {
+		annotations, err := ghl.Annotations(ctx, run, id)
+		return annotationsMsg{runId: run.RunId, jobName: jobName, annotations: annotations, err: err}
+	}
+}
+
+func (m *model) setAnnotations(msg annotationsMsg) {
+	if msg.err != nil {
+		m.notice = fmt.Sprintf("couldn't load annotations: %s", errors.Cause(msg.err))
+		return
+	}
+
+	for _, t := range m.tails {
+		if *t.wfRun.ID == msg.runId && t.jobName == msg.jobName {
+			t.annotations = msg.annotations
+		}
+	}
+
+	if t := m.tail(); t != nil {
+		m.annotations.cursor = max(0, min(len(t.annotations)-1, m.annotations.cursor))
+	}
+}
+
+func (m model) currentAnnotations() []*github.CheckRunAnnotation {
+	if t := m.tail(); t != nil {
+		return t.annotations
+	}
+	return nil
+}
+
+// updateAnnotations handles keys while the annotations panel, which lists the
+// check run annotations of the tailed job, has the focus.
+func (m model) updateAnnotations(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	list := m.currentAnnotations()
+	cmd := m.updatePanel(&m.annotations, msg, "a", len(list), func(cursor int) tea.Cmd {
+		m.jumpToAnnotation(list[cursor])
+		return nil
+	})
+	return m, cmd
+}
+
+// jumpToAnnotation scrolls to the log line that an annotation was made from,
+// i.e. the ##[error] (or warning, or notice) line with the same message.
+func (m *model) jumpToAnnotation(annotation *github.CheckRunAnnotation) {
+	t := m.tail()
+	message, _, _ := strings.Cut(annotation.GetMessage(), "\n")
+	if message == "" {
+		return
+	}
+
+	found := -1
+	t.eachLine(0, func(idx int, line logfmt.Line) bool {
+		switch t.lines[idx].kind {
+		case logfmt.KindError, logfmt.KindWarning, logfmt.KindNotice:
+			if strings.Contains(line.Text, message) {
+				found = idx
+				return false
+			}
+		}
+		return true
+	})
+
+	if found < 0 {
+		m.notice = "no matching log line"
+		return
+	}
+
+	m.reveal(found)
+	m.refreshContent()
+	m.scrollTo(m.rowOf(found))
+}
+
+func (m model) annotationsStatus() string {
+	n := len(m.currentAnnotations())
+	switch {
+	case m.annotations.visible || n == 0:
+		return ""
+	case n == 1:
+		return "1 annotation (a)"
+	default:
+		return fmt.Sprintf("%d annotations (a)", n)
+	}
+}
+
+// annotationsViewHeight is how many rows the panel takes up, if it's visible.
+func (m model) annotationsViewHeight() int {
+	if !m.annotations.visible {
+		return 0
+	}
+	return annotationsHeight + 1 // the border
+}
+
+func (m model) annotationsView() string {
+	list := m.currentAnnotations()
+
+	lines := []string{}
+	for _, annotation := range list {
+		style, level := noticeStyle, "notice"
+		switch annotation.GetAnnotationLevel() {
+		case "failure":
+			style, level = errorStyle, "error"
+		case "warning":
+			style, level = warningStyle, "warning"
+		}
+
+		location := annotation.GetPath()
+		if line := annotation.GetStartLine(); line > 0 {
+			location = fmt.Sprintf("%s:%d", location, line)
+		}
+
+		message, _, _ := strings.Cut(annotation.GetMessage(), "\n")
+		message = truncate(message, max(1, m.width-len(level)-len(location)-3))
+		lines = append(lines, fmt.Sprintf("%s %s %s", style.Render(fmt.Sprintf("%-7s", level)), dimStyle.Render(location), message))
+	}
+
+	view := m.annotations.view(lines, dimStyle.Render("no annotations"), annotationsHeight)
+	return annotationsStyle.Width(m.width).Height(annotationsHeight).MaxHeight(annotationsHeight + 1).Render(view)
+}
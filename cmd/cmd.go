@@ -3,20 +3,18 @@ package main
 import (
 	"context"
 	"fmt"
-	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/config"
 	"github.com/aidansteele/ghal/repoinfo"
 	"github.com/aidansteele/ghal/runs"
-	"github.com/charmbracelet/bubbles/viewport"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v43/github"
 	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime/debug"
 	"strings"
-	"time"
 )
 
 var (
@@ -50,285 +48,174 @@ func readBuildInfo() buildInfo {
 }
 
 func main() {
-	if len(os.Args) >= 2 && os.Args[1] == "version" {
-		info := readBuildInfo()
-		fmt.Printf(`
-version: %s
-commit: %s
-build date: %s
-`, info.version, info.commit, info.date)
-		return
-	}
-
-	ctx := context.Background()
-
-	repo := repoinfo.Info()
-
-	client := http.DefaultClient
-	api := github.NewClient(oauth2.NewClient(
-		context.WithValue(ctx, oauth2.HTTPClient, client),
-		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: repo.Token}),
-	))
-
-	workflowFileName := os.Args[1]
-	jobName := os.Args[2]
-
-	ghl := ghlogs.New(
-		api,
-		client,
-		os.Getenv("GITHUB_USER_SESSION"),
-	)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	allRunsCh := make(chan *github.WorkflowRun)
-	tailedRunsCh := make(chan *github.WorkflowRun)
-	tailOutputCh := make(chan ghlogs.RunOutput)
-
-	go runs.Monitor(ctx, api.Actions, allRunsCh, repo.Owner, repo.Repo, workflowFileName)
-	go monitorRuns(ctx, ghl, allRunsCh, tailedRunsCh, tailOutputCh)
-	tailOutput(tailedRunsCh, tailOutputCh, jobName)
-}
+	err := newRootCmd().ExecuteContext(ctx)
+	if err != nil {
+		stop()
 
-func monitorRuns(ctx context.Context, ghl *ghlogs.Ghlogs, runch, tailedRunch chan *github.WorkflowRun, tailch chan ghlogs.RunOutput) {
-	prevCancel := func() {}
-	newCtx := ctx
-	for run := range runch {
-		prevCancel()
-		newCtx, prevCancel = context.WithCancel(ctx)
-
-		go func(ctx context.Context, run *github.WorkflowRun) {
-			tailedRunch <- run
-			err := ghl.Logs(ctx, tailch, ghlogs.Run{
-				Owner: *run.Repository.Owner.Login,
-				Repo:  *run.Repository.Name,
-				RunId: *run.ID,
-			})
-
-			ctxerr := ctx.Err()
-			cause := errors.Cause(err)
-			if err != nil && cause != ctxerr {
-				fmt.Printf("%+v\n", err)
-				panic(err)
-			}
-		}(newCtx, run)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		os.Exit(1)
 	}
 }
 
-type stepNameOffset struct {
-	stepName string
-	offset   int
+// exitError makes ghal exit with a specific code, for commands that scripts
+// rely on.
+type exitError struct {
+	code int
+	err  error
 }
 
-type model struct {
-	wfRun     *github.WorkflowRun
-	jobName   string
-	stepNames []stepNameOffset
-
-	tailch   chan ghlogs.RunOutput
-	runch    chan *github.WorkflowRun
-	buffer   *strings.Builder
-	ready    bool
-	viewport viewport.Model
+func (e *exitError) Error() string {
+	return e.err.Error()
 }
 
-func (m model) Init() tea.Cmd {
-	return tea.Batch(
-		m.waitForActivity(),
-		tick(time.Second),
-	)
-}
-
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var (
-		cmd  tea.Cmd
-		cmds []tea.Cmd
-	)
-
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		// These keys should exit the program.
-		case "ctrl+c", "q":
-			return m, tea.Quit
-		}
-	case tickMsg:
-		return m, tick(time.Second)
-	case *github.WorkflowRun:
-		m.wfRun = msg
-		m.reset()
-		cmds = append(cmds, m.waitForActivity())
-	case ghlogs.RunOutput:
-		m.append(msg)
-		m.viewport.SetContent(m.buffer.String())
-		m.viewport.GotoBottom()
-		cmds = append(cmds, m.waitForActivity())
-	case tea.WindowSizeMsg:
-		headerHeight := lipgloss.Height(m.headerView())
-		footerHeight := lipgloss.Height(m.footerView())
-		verticalMarginHeight := headerHeight + footerHeight
-
-		if !m.ready {
-			// Since this program is using the full size of the viewport we
-			// need to wait until we've received the window dimensions before
-			// we can initialize the viewport. The initial dimensions come in
-			// quickly, though asynchronously, which is why we wait for them
-			// here.
-			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
-			m.viewport.YPosition = headerHeight
-			m.viewport.HighPerformanceRendering = false
-			m.viewport.SetContent(m.buffer.String())
-			m.ready = true
-
-			// This is only necessary for high performance rendering, which in
-			// most cases you won't need.
-			//
-			// Render the viewport one line below the header.
-			m.viewport.YPosition = headerHeight + 1
-		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - verticalMarginHeight
-		}
-	}
-
-	m.viewport, cmd = m.viewport.Update(msg)
-	cmds = append(cmds, cmd)
+// globalOptions are the flags shared by every subcommand.
+type globalOptions struct {
+	repo   string
+	branch string
+	output string
 
-	return m, tea.Batch(cmds...)
+	config        *config.Config
+	configSources []string
 }
 
-func (m *model) reset() {
-	m.buffer.Reset()
-	m.viewport.SetContent("")
-	m.stepNames = []stepNameOffset{{stepName: "", offset: -1}}
-}
-
-func (m *model) append(output ghlogs.RunOutput) {
-	if output.Run.RunId != *m.wfRun.ID {
-		return
-	}
-
-	if output.JobName != m.jobName {
-		return
-	}
+func newRootCmd() *cobra.Command {
+	g := &globalOptions{}
+	tail := newTailCmd(g)
 
-	stepName := output.StepName
-	if output.AssumedStepName {
-		stepName += "*"
-	}
+	root := &cobra.Command{
+		Use:   "ghal [workflow file] [job name]",
+		Short: "Stream live GitHub Actions build logs to your terminal",
+		Long: `ghal streams live GitHub Actions build logs to your terminal.
 
-	for _, line := range output.Lines {
-		fmt.Fprintln(m.buffer, line)
-	}
+Running ghal without a subcommand is the same as "ghal tail".`,
+		Example:      "  ghal e2e.yml deploy",
+		Args:         tail.Args,
+		RunE:         tail.RunE,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !usesConfig(cmd) {
+				return nil
+			}
+			return g.loadConfig()
+		},
+	}
+
+	root.PersistentFlags().StringVarP(&g.repo, "repo", "R", "", "repository in the format OWNER/REPO (default: from git remotes)")
+	root.PersistentFlags().StringVarP(&g.branch, "branch", "b", "", "only consider runs for this branch")
+	root.PersistentFlags().StringVarP(&g.output, "output", "o", "", "output format (depends on the command)")
+	root.Flags().AddFlagSet(tail.Flags())
+
+	root.AddCommand(
+		tail,
+		newLogsCmd(g),
+		newRunsCmd(g),
+		newWatchCmd(g),
+		newWaitCmd(g),
+		newConfigCmd(g),
+		newVersionCmd(),
+	)
 
-	latestStep := m.stepNames[len(m.stepNames)-1].stepName
-	if stepName != latestStep {
-		offset := len(strings.Split(m.buffer.String(), "\n"))
-		m.stepNames = append(m.stepNames, stepNameOffset{
-			stepName: stepName,
-			offset:   offset,
-		})
-	}
+	return root
 }
 
-var titleStyle = func() lipgloss.Style {
-	b := lipgloss.RoundedBorder()
-	b.Right = "├"
-	return lipgloss.NewStyle().
-		BorderStyle(b).
-		Padding(0, 1).
-		Foreground(lipgloss.Color("#067D17"))
-	//Foreground(lipgloss.AdaptiveColor{
-	//	Light: "#067D17",
-	//	Dark:  "#82ED7D",
-	//})
-}()
-
-func (m model) headerView() string {
-	yoff := m.viewport.YOffset
-	stepName := m.stepNames[len(m.stepNames)-1].stepName
-	for _, name := range m.stepNames {
-		if name.offset < yoff {
-			stepName = name.stepName
+// usesConfig reports whether a command reads the config file. Those that
+// don't shouldn't have to resolve the repo, or fail when the file is broken.
+func usesConfig(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		switch cmd.Name() {
+		case "version", "help", "completion":
+			return false
 		}
 	}
+	return true
+}
 
-	wfName := ""
-	runNumber := -1
-	if m.wfRun != nil {
-		wfName = *m.wfRun.Name
-		runNumber = *m.wfRun.RunNumber
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			info := readBuildInfo()
+			fmt.Printf(`
+version: %s
+commit: %s
+build date: %s
+`, info.version, info.commit, info.date)
+		},
 	}
+}
 
-	title := titleStyle.Render(fmt.Sprintf("%s / %s / %s (#%d)", wfName, m.jobName, stepName, runNumber))
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
+// session holds everything needed to talk to GitHub about a single repo.
+type session struct {
+	repo   *repoinfo.RepoInfo
+	client *http.Client
+	api    *github.Client
 }
 
-var infoStyle = func() lipgloss.Style {
-	b := lipgloss.RoundedBorder()
-	b.Left = "┤"
-	return lipgloss.NewStyle().BorderStyle(b).Padding(0, 1)
-}()
-
-func (m model) footerView() string {
-	duration := "-"
-	if m.wfRun != nil {
-		dur := time.Now().Sub(m.wfRun.RunStartedAt.Time).Truncate(time.Second)
-		duration = dur.String()
+func (g *globalOptions) session(ctx context.Context) (*session, error) {
+	repo, err := repoinfo.Info(g.repo)
+	if err != nil {
+		return nil, err
 	}
 
-	info := infoStyle.Render(duration)
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
+	client := http.DefaultClient
+	api := github.NewClient(oauth2.NewClient(
+		context.WithValue(ctx, oauth2.HTTPClient, client),
+		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: repo.Token}),
+	))
+
+	return &session{
+		repo:   repo,
+		client: client,
+		api:    api,
+	}, nil
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+// filter narrows runs down to the --branch flag, or else the config file's
+// default branch.
+func (g *globalOptions) filter() runs.Filter {
+	branch := g.branch
+	if branch == "" && g.config != nil {
+		branch = g.config.Branch
 	}
-	return b
+	return runs.Filter{Branch: branch}
 }
 
-func (m model) View() string {
-	if !m.ready {
-		return "\n  Initializing..."
+// outputFormat validates the --output flag against the formats a command
+// supports. The first supported format is the default.
+func (g *globalOptions) outputFormat(supported ...string) (string, error) {
+	if g.output == "" {
+		return supported[0], nil
 	}
-	return fmt.Sprintf("%s\n%s\n%s", m.headerView(), m.viewport.View(), m.footerView())
-}
 
-func (m model) waitForActivity() tea.Cmd {
-	return func() tea.Msg {
-		select {
-		case msg := <-m.tailch:
-			return msg
-		case msg := <-m.runch:
-			return msg
+	for _, format := range supported {
+		if g.output == format {
+			return format, nil
 		}
 	}
-}
 
-type tickMsg int
-
-func tick(d time.Duration) tea.Cmd {
-	return tea.Tick(d, func(_ time.Time) tea.Msg {
-		return tickMsg(0)
-	})
+	return "", errors.Errorf("unsupported output format %q, expected one of: %s", g.output, strings.Join(supported, ", "))
 }
 
-func tailOutput(runch chan *github.WorkflowRun, ch chan ghlogs.RunOutput, jobName string) {
-	m := model{
-		jobName: jobName,
+// namedArgs is like cobra.RangeArgs, but names the missing arguments in its
+// error message, e.g. `missing argument: job name`.
+func namedArgs(required int, names ...string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) > len(names) {
+			return errors.Errorf("expected at most %d arguments, got %d: %s", len(names), len(args), strings.Join(args, " "))
+		}
 
-		runch:    runch,
-		tailch:   ch,
-		buffer:   &strings.Builder{},
-		viewport: viewport.Model{},
-	}
-	m.reset()
+		if len(args) < required {
+			return errors.Errorf("missing argument: %s\n\nUsage:\n  %s", names[len(args)], cmd.UseLine())
+		}
 
-	p := tea.NewProgram(m)
-	err := p.Start()
-	if err != nil {
-		fmt.Printf("%+v\n", err)
-		panic(err)
+		return nil
 	}
 }
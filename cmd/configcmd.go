@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aidansteele/ghal/config"
+	"github.com/aidansteele/ghal/repoinfo"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strings"
+)
+
+func newConfigCmd(g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Print the configuration, merged from every config file",
+		Long: `Print the configuration, merged from every config file.
+
+Configuration is read from (in order, with later files taking precedence):
+
+  - the user config file, $GHAL_CONFIG or e.g. ~/.config/ghal/config.yml
+  - its section for the current repo, under repos.OWNER/REPO
+  - .github/ghal.yml in the current repo
+
+Command-line flags take precedence over all of them.
+
+The actions that can be rebound under keys: are:
+  ` + strings.Join(keyActions(), ", "),
+		Example: `  # ~/.config/ghal/config.yml
+  branch: main
+  aliases:
+    prod:
+      workflow: deploy.yml
+      job: Deploy to production
+  colors:
+    error: "#FF5F5F"
+  keys:
+    search: ctrl+f`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfig(g)
+		},
+	}
+}
+
+func runConfig(g *globalOptions) error {
+	format, err := g.outputFormat("yaml", "json")
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(g.config))
+	}
+
+	if len(g.configSources) == 0 {
+		fmt.Printf("# no config files found (looked for %s)\n", config.UserFile())
+	}
+	for _, source := range g.configSources {
+		fmt.Printf("# from %s\n", source)
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	return errors.WithStack(enc.Encode(g.config))
+}
+
+// loadConfig reads the config files. The repo's section of the user config
+// file is found using --repo or the git remotes, if possible.
+func (g *globalOptions) loadConfig() error {
+	nwo := g.repo
+	if nwo == "" {
+		if repo, err := repoinfo.Info(""); err == nil {
+			nwo = repo.Owner + "/" + repo.Repo
+		}
+	}
+
+	cfg, sources, err := config.Load(config.UserFile(), config.RepoFile("."), nwo)
+	if err != nil {
+		return err
+	}
+
+	g.config, g.configSources = cfg, sources
+	return nil
+}
+
+// styles are the styles whose colour can be set in the config file.
+var styles = map[string]*lipgloss.Style{
+	"error":   &errorStyle,
+	"warning": &warningStyle,
+	"notice":  &noticeStyle,
+	"group":   &groupStyle,
+	"dim":     &dimStyle,
+	"match":   &searchMatchStyle,
+	"title":   &titleStyle,
+	"prefix":  &prefixStyle,
+}
+
+// applyColors sets the colours from the config file. The search match
+// colour is its background, the others are foregrounds.
+func applyColors(colors map[string]string) error {
+	for name, color := range colors {
+		style, ok := styles[name]
+		if !ok {
+			names := make([]string, 0, len(styles))
+			for name := range styles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return errors.Errorf("unknown colour %q in config, expected one of: %s", name, strings.Join(names, ", "))
+		}
+
+		if name == "match" {
+			*style = style.Copy().Background(lipgloss.Color(color))
+		} else {
+			*style = style.Copy().Foreground(lipgloss.Color(color))
+		}
+	}
+
+	return nil
+}
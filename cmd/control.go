@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/runs"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cli/browser"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"io/ioutil"
+)
+
+// confirmation is an action that waits for the user to press y before it
+// runs. Any other key abandons it.
+type confirmation struct {
+	prompt string
+	action tea.Cmd
+}
+
+// actionResultMsg reports how an action went, to be shown in the footer.
+type actionResultMsg struct {
+	notice string
+}
+
+var confirmStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#D7A000"))
+
+func runOf(wfRun *github.WorkflowRun) ghlogs.Run {
+	return ghlogs.Run{
+		Owner: *wfRun.Repository.Owner.Login,
+		Repo:  *wfRun.Repository.Name,
+		RunId: *wfRun.ID,
+	}
+}
+
+func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action := m.confirm.action
+	m.confirm = nil
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "y", "Y":
+		return m, action
+	}
+
+	return m, nil
+}
+
+func (m model) confirmStatus() string {
+	if m.confirm == nil {
+		return ""
+	}
+	return confirmStyle.Render(m.confirm.prompt + " (y/N)")
+}
+
+// cancelRun asks to cancel the current run.
+func (m *model) cancelRun() {
+	t := m.tail()
+	if t == nil {
+		return
+	}
+
+	if t.completed() {
+		m.notice = fmt.Sprintf("run #%d has already completed", t.wfRun.GetRunNumber())
+		return
+	}
+
+	m.confirmRunAction(t, "cancel", m.ghl.Cancel, false)
+}
+
+// rerun asks to start a new attempt of the current run, with either every job
+// or only the failed ones re-run. The new attempt is tailed once it starts.
+func (m *model) rerun(failedOnly bool) {
+	t := m.tail()
+	if t == nil {
+		return
+	}
+
+	if !t.completed() {
+		m.notice = fmt.Sprintf("run #%d is still in progress", t.wfRun.GetRunNumber())
+		return
+	}
+
+	if failedOnly {
+		m.confirmRunAction(t, "re-run failed jobs of", m.ghl.RerunFailed, true)
+	} else {
+		m.confirmRunAction(t, "re-run", m.ghl.Rerun, true)
+	}
+}
+
+func (m *model) confirmRunAction(t *tailedRun, verb string, fn func(context.Context, ghlogs.Run) error, rerun bool) {
+	ctx := m.ctx
+	run := runOf(t.wfRun)
+	reruns := m.streams.reruns
+	attempt := t.wfRun.GetRunAttempt()
+	number := t.wfRun.GetRunNumber()
+
+	m.confirm = &confirmation{
+		prompt: fmt.Sprintf("%s run #%d?", verb, number),
+		action: func() tea.Msg {
+			err := fn(ctx, run)
+			if err != nil {
+				return actionResultMsg{notice: fmt.Sprintf("couldn't %s run #%d: %s", verb, number, errors.Cause(err))}
+			}
+
+			if rerun && reruns != nil {
+				select {
+				case reruns <- runs.Rerun{RunId: run.RunId, Attempt: attempt}:
+				case <-ctx.Done():
+				}
+			}
+
+			return actionResultMsg{notice: fmt.Sprintf("requested %s run #%d", verb, number)}
+		},
+	}
+}
+
+// open shows the current run, or the tailed job within it, in a browser.
+func (m *model) open(job bool) tea.Cmd {
+	t := m.tail()
+	if t == nil {
+		return nil
+	}
+
+	url := t.wfRun.GetHTMLURL()
+	if job {
+		if t.job == nil {
+			m.notice = "the job hasn't started yet"
+			return nil
+		}
+		url = t.job.GetHTMLURL()
+	}
+
+	return func() tea.Msg {
+		// the browser's own output would corrupt the screen
+		browser.Stdout = ioutil.Discard
+		browser.Stderr = ioutil.Discard
+
+		err := browser.OpenURL(url)
+		if err != nil {
+			return actionResultMsg{notice: fmt.Sprintf("couldn't open %s: %s", url, err)}
+		}
+
+		return actionResultMsg{notice: "opened " + url}
+	}
+}
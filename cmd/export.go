@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/aidansteele/ghal/logfmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// exportFormats are the ways a buffer can be saved or copied. plain is what
+// the GitHub UI shows: no timestamps, workflow command markers or ANSI
+// escapes. raw is the output exactly as it was received.
+var exportFormats = []string{"plain", "raw"}
+
+// bufferText returns the lines [from, to) of the current run in the chosen
+// format.
+func (m model) bufferText(from, to int) (string, error) {
+	raws, err := m.tail().store.Lines(from, to)
+	if err != nil {
+		return "", err
+	}
+
+	sb := &strings.Builder{}
+	for _, raw := range raws {
+		if m.exportFormat == "raw" {
+			sb.WriteString(raw)
+			sb.WriteByte('\n')
+			continue
+		}
+
+		line := logfmt.Parse(raw)
+		if line.Kind == logfmt.KindEndGroup {
+			continue
+		}
+
+		sb.WriteString(logfmt.StripANSI(line.Text))
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
+
+// stepRange returns the lines of the step at the top of the viewport, and
+// its number.
+func (m model) stepRange() (int, int, int) {
+	t := m.tail()
+	top := m.lineAt(m.viewport.YOffset)
+
+	from, to, number := 0, len(t.lines), 0
+	for _, name := range t.stepNames {
+		if name.offset < 0 {
+			continue
+		}
+		if name.offset <= top {
+			from, number = name.offset, name.stepNumber
+		} else if name.offset < to {
+			to = name.offset
+			break
+		}
+	}
+
+	return from, to, number
+}
+
+var unsafeFileNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// exportFileName generates a name like ghal-e2e-deploy-1234-1.log, or
+// ghal-e2e-deploy-1234-1-step3.log for a single step. Lines before the first
+// step are step 0, so that saving them doesn't overwrite the whole job.
+func (m model) exportFileName(step bool, number int) string {
+	t := m.tail()
+	name := fmt.Sprintf("ghal-%s-%s-%d-%d", t.wfRun.GetName(), m.jobLabel(t), t.wfRun.GetID(), t.wfRun.GetRunAttempt())
+	if step {
+		name += fmt.Sprintf("-step%d", number)
+	}
+
+	name = strings.Trim(unsafeFileNameRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	return name + ".log"
+}
+
+// save writes the current run's buffer, or only the current step, to a file
+// in the working directory.
+func (m *model) save(step bool) {
+	t := m.tail()
+	if t == nil {
+		return
+	}
+
+	from, to, number := 0, len(t.lines), 0
+	if step {
+		from, to, number = m.stepRange()
+	}
+
+	name := m.exportFileName(step, number)
+	text, err := m.bufferText(from, to)
+	if err == nil {
+		err = ioutil.WriteFile(name, []byte(text), 0644)
+	}
+	if err != nil {
+		m.notice = fmt.Sprintf("save failed: %s", errors.Cause(err))
+		return
+	}
+
+	m.notice = fmt.Sprintf("saved %d lines to %s", to-from, name)
+}
+
+// clipboardLimit is the most text that is copied in one go. Terminals limit
+// the size of an OSC 52 sequence - xterm and tmux to around 100 KB once it is
+// base64 encoded - and drop or truncate anything longer.
+const clipboardLimit = 64 * 1024
+
+// copy puts the current run's buffer, or only the current step, on the
+// clipboard. Past clipboardLimit only the leading whole lines are copied.
+func (m *model) copy(step bool) tea.Cmd {
+	t := m.tail()
+	if t == nil {
+		return nil
+	}
+
+	from, to := 0, len(t.lines)
+	if step {
+		from, to, _ = m.stepRange()
+	}
+
+	text, err := m.bufferText(from, to)
+	if err != nil {
+		m.notice = fmt.Sprintf("copy failed: %s", errors.Cause(err))
+		return nil
+	}
+
+	m.notice = fmt.Sprintf("copied %d lines", to-from)
+	if len(text) > clipboardLimit {
+		text = text[:strings.LastIndexByte(text[:clipboardLimit], '\n')+1]
+		if text == "" {
+			m.notice = "too long to copy to the clipboard, w saves it to a file instead"
+			return nil
+		}
+		m.notice = fmt.Sprintf("copied only the first %d of %d lines, the clipboard can't take more (w saves them all)", strings.Count(text, "\n"), to-from)
+	}
+
+	return writeTerminal(clipboardSeq(text))
+}
+
+// clipboardSeq sets the clipboard with an OSC 52 escape sequence, which the
+// terminal emulator handles - so it also works over SSH.
+func clipboardSeq(text string) string {
+	return terminalSeq(fmt.Sprintf("\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text))))
+}
+
+// terminalSeq wraps an escape sequence for the terminal emulator itself.
+// Inside tmux the sequence has to be passed through to the outer terminal.
+func terminalSeq(seq string) string {
+	if os.Getenv("TMUX") != "" {
+		seq = fmt.Sprintf("\x1bPtmux;%s\x1b\\", strings.ReplaceAll(seq, "\x1b", "\x1b\x1b"))
+	}
+	return seq
+}
+
+// terminalMsg is an escape sequence for the terminal emulator. The TUI writes
+// it from Update, as Bubble Tea owns the terminal while it runs.
+type terminalMsg string
+
+func writeTerminal(seq string) tea.Cmd {
+	return func() tea.Msg {
+		return terminalMsg(seq)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// lineFilter hides lines of output that don't match include, or that do
+// match exclude. ##[group] and ##[endgroup] markers are never hidden, so the
+// structure of the output is kept.
+type lineFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+func newLineFilter(include, exclude string) (lineFilter, error) {
+	f := lineFilter{}
+
+	if include != "" {
+		re, err := regexp.Compile(include)
+		if err != nil {
+			return f, errors.Errorf("invalid --grep regex %q: %s", include, err)
+		}
+		f.include = re
+	}
+
+	if exclude != "" {
+		re, err := regexp.Compile(exclude)
+		if err != nil {
+			return f, errors.Errorf("invalid --grep-v regex %q: %s", exclude, err)
+		}
+		f.exclude = re
+	}
+
+	return f, nil
+}
+
+func (f lineFilter) active() bool {
+	return f.include != nil || f.exclude != nil
+}
+
+// hides reports whether a line should be left out.
+func (f lineFilter) hides(line logfmt.Line) bool {
+	if line.Kind == logfmt.KindGroup || line.Kind == logfmt.KindEndGroup || !f.active() {
+		return false
+	}
+
+	text := logfmt.StripANSI(line.Text)
+	if f.include != nil && !f.include.MatchString(text) {
+		return true
+	}
+	return f.exclude != nil && f.exclude.MatchString(text)
+}
+
+// String is the filter the way it's typed into the filter bar: a regex to
+// keep matching lines, or one prefixed with ! to hide them.
+func (f lineFilter) String() string {
+	parts := []string{}
+	if f.include != nil {
+		parts = append(parts, f.include.String())
+	}
+	if f.exclude != nil {
+		parts = append(parts, "!"+f.exclude.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitFilter is the inverse of lineFilter.String: `regex`, `!regex` or
+// `regex !regex`.
+func splitFilter(text string) (string, string) {
+	if strings.HasPrefix(text, "!") {
+		return "", text[1:]
+	}
+
+	if idx := strings.LastIndex(text, " !"); idx >= 0 {
+		return text[:idx], text[idx+2:]
+	}
+
+	return text, ""
+}
+
+// filterBar is where the TUI's filter is typed.
+type filterBar struct {
+	typing bool
+	input  textinput.Model
+	err    error
+}
+
+func newFilterBar() filterBar {
+	input := textinput.New()
+	input.Prompt = "filter: "
+	return filterBar{input: input}
+}
+
+func (m *model) startFilter() tea.Cmd {
+	m.filterBar.typing = true
+	m.filterBar.err = nil
+	m.filterBar.input.SetValue(m.filter.String())
+	m.filterBar.input.CursorEnd()
+	return m.filterBar.input.Focus()
+}
+
+// updateFilter handles keys while the filter is being typed.
+func (m model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.filterBar.typing = false
+		m.filterBar.input.Blur()
+		return m, nil
+	case "enter":
+		m.filterBar.typing = false
+		m.filterBar.input.Blur()
+
+		f, err := newLineFilter(splitFilter(m.filterBar.input.Value()))
+		if err != nil {
+			m.filterBar.err = errors.Cause(err)
+			return m, nil
+		}
+
+		m.setFilter(f)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filterBar.input, cmd = m.filterBar.input.Update(msg)
+	return m, cmd
+}
+
+// setFilter changes the filter, and applies it to every line buffered so far.
+func (m *model) setFilter(f lineFilter) {
+	m.filter = f
+	for _, t := range m.tails {
+		t.applyFilter(f)
+	}
+
+	m.eachPane(func(p *model) {
+		top := p.lineAt(p.viewport.YOffset)
+		p.refreshContent()
+		if p.following {
+			p.viewport.GotoBottom()
+		} else {
+			p.viewport.SetYOffset(p.rowOf(top))
+		}
+	})
+}
+
+// applyFilter works out which of the lines buffered so far the filter hides.
+func (t *tailedRun) applyFilter(f lineFilter) {
+	t.filtered = 0
+	for idx := range t.lines {
+		t.lines[idx].filtered = false
+	}
+
+	t.eachLine(0, func(idx int, line logfmt.Line) bool {
+		if idx < len(t.lines) && f.hides(line) {
+			t.lines[idx].filtered = true
+			t.filtered++
+		}
+		return true
+	})
+
+	t.invalidate(0)
+}
+
+func (m model) filterStatus() string {
+	switch {
+	case m.filterBar.typing:
+		return m.filterBar.input.View()
+	case m.filterBar.err != nil:
+		return m.filterBar.err.Error()
+	case !m.filter.active():
+		return ""
+	}
+
+	hidden := 0
+	if t := m.tail(); t != nil {
+		hidden = t.filtered
+	}
+
+	if hidden == 1 {
+		return fmt.Sprintf("filter: %s — 1 line hidden", m.filter)
+	}
+	return fmt.Sprintf("filter: %s — %d lines hidden", m.filter, hidden)
+}
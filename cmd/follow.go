@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Like `less +F`, the viewport follows new output until the user scrolls
+// away from the bottom. It resumes following on G or End.
+
+// scrollTo shows the given row at the top of the viewport. This stops
+// following new output, unless the viewport is still at the bottom.
+func (m *model) scrollTo(row int) {
+	m.viewport.SetYOffset(row)
+	if !m.viewport.AtBottom() {
+		m.pause()
+	}
+}
+
+func (m *model) pause() {
+	if m.following {
+		m.following = false
+		m.unseen = 0
+	}
+}
+
+func (m *model) follow() {
+	m.following = true
+	m.unseen = 0
+	m.viewport.GotoBottom()
+}
+
+func (m model) followStatus() string {
+	switch {
+	case m.tail() != nil && m.tail().archived:
+		return "history (L to go back to live)"
+	case m.following:
+		return ""
+	case m.unseen == 1:
+		return "paused — 1 new line (G to follow)"
+	case m.unseen > 1:
+		return fmt.Sprintf("paused — %d new lines (G to follow)", m.unseen)
+	default:
+		return "paused (G to follow)"
+	}
+}
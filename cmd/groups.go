@@ -0,0 +1,224 @@
+package main
+
+import (
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/charmbracelet/lipgloss"
+	"time"
+)
+
+// logLine is what's needed to lay out a line of output without reading it
+// from the store: its kind and the group it belongs to (or -1). A group's
+// ##[group] line belongs to the group it starts.
+type logLine struct {
+	kind     logfmt.Kind
+	group    int
+	at       time.Time     // the line's timestamp, or when it arrived if it had none
+	gap      time.Duration // since the previous line
+	filtered bool          // hidden by the filter
+}
+
+// logGroup is a collapsible ##[group] ... ##[endgroup] section. Like the
+// GitHub UI, groups collapse once they end.
+type logGroup struct {
+	line      int
+	collapsed bool
+}
+
+var (
+	groupStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Reverse(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#CC0000"))
+	warningStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#D7A000"))
+	noticeStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#3B8EEA"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+	plainStyle    = lipgloss.NewStyle()
+)
+
+func (t *tailedRun) appendLine(line logfmt.Line, received time.Time) {
+	idx := len(t.lines)
+
+	at := line.Timestamp
+	if at.IsZero() {
+		at = received
+	}
+
+	gap := time.Duration(0)
+	if idx > 0 {
+		gap = at.Sub(t.lines[idx-1].at)
+	}
+
+	t.invalidate(idx)
+
+	switch line.Kind {
+	case logfmt.KindGroup:
+		t.closeGroup()
+		t.openGroup = len(t.groups)
+		t.groups = append(t.groups, &logGroup{line: idx})
+		t.lines = append(t.lines, logLine{kind: line.Kind, group: t.openGroup, at: at, gap: gap})
+	case logfmt.KindEndGroup:
+		t.lines = append(t.lines, logLine{kind: line.Kind, group: t.openGroup, at: at, gap: gap})
+		t.closeGroup()
+	default:
+		t.lines = append(t.lines, logLine{kind: line.Kind, group: t.openGroup, at: at, gap: gap})
+	}
+}
+
+func (t *tailedRun) closeGroup() {
+	if t.openGroup < 0 {
+		return
+	}
+
+	group := t.groups[t.openGroup]
+	group.collapsed = !t.expandAll
+	t.invalidate(group.line)
+	t.openGroup = -1
+}
+
+// visible reports whether a line is shown, i.e. it isn't an ##[endgroup],
+// hidden by the filter or inside a collapsed group.
+func (t *tailedRun) visible(idx int) bool {
+	line := t.lines[idx]
+	if line.kind == logfmt.KindEndGroup || line.filtered {
+		return false
+	}
+
+	if line.group < 0 || line.kind == logfmt.KindGroup {
+		return true
+	}
+
+	return !t.groups[line.group].collapsed
+}
+
+func (m model) renderLine(t *tailedRun, idx int) string {
+	line := t.lines[idx]
+	text := t.parsed(idx).Text
+
+	prefix := m.timestampPrefix(t, idx)
+
+	style, label := plainStyle, ""
+	switch line.kind {
+	case logfmt.KindGroup:
+		style, label = groupStyle, "▾ "
+		if t.groups[line.group].collapsed {
+			label = "▸ "
+		}
+		if line.group == t.selectedGroup {
+			style = selectedStyle
+		}
+	case logfmt.KindError:
+		style, label = errorStyle, "Error: "
+	case logfmt.KindWarning:
+		style, label = warningStyle, "Warning: "
+	case logfmt.KindNotice:
+		style, label = noticeStyle, "Notice: "
+	case logfmt.KindCommand, logfmt.KindDebug:
+		style = dimStyle
+	}
+
+	if line.group >= 0 && line.kind != logfmt.KindGroup {
+		prefix += "  "
+	}
+
+	if line.kind == logfmt.KindOutput && !m.search.matchesLine(idx) {
+		return prefix + text
+	}
+
+	return prefix + style.Render(label) + m.search.highlightLine(idx, text, style)
+}
+
+// reveal expands the group containing a line, so that it's visible.
+func (m *model) reveal(idx int) {
+	t := m.tail()
+	if t == nil || idx >= len(t.lines) {
+		return
+	}
+
+	if group := t.lines[idx].group; group >= 0 {
+		t.groups[group].collapsed = false
+		t.invalidate(t.groups[group].line)
+	}
+}
+
+// selectGroup selects the next (or previous, when dir is negative) group
+// after the line at the top of the viewport, and scrolls to it.
+func (m *model) selectGroup(dir int) {
+	t := m.tail()
+	if t == nil || len(t.groups) == 0 {
+		return
+	}
+
+	top := m.lineAt(m.viewport.YOffset)
+	selected := -1
+	for idx, group := range t.groups {
+		if dir > 0 && group.line > top {
+			selected = idx
+			break
+		}
+		if dir < 0 && group.line < top {
+			selected = idx
+		}
+	}
+
+	if selected < 0 {
+		return
+	}
+
+	t.selectedGroup = selected
+	m.refreshContent()
+	m.scrollTo(m.rowOf(t.groups[selected].line))
+}
+
+// toggleGroup expands or collapses the selected group, or otherwise the group
+// that the line at the top of the viewport belongs to.
+func (m *model) toggleGroup() {
+	t := m.tail()
+	if t == nil {
+		return
+	}
+
+	selected := t.selectedGroup
+	if selected < 0 && len(t.lines) > 0 {
+		selected = t.lines[m.lineAt(m.viewport.YOffset)].group
+	}
+
+	if selected < 0 {
+		return
+	}
+
+	group := t.groups[selected]
+	group.collapsed = !group.collapsed
+	t.selectedGroup = selected
+	t.invalidate(group.line)
+
+	m.refreshContent()
+	m.scrollTo(m.rowOf(group.line))
+}
+
+// toggleAllGroups expands every group if any are collapsed, otherwise it
+// collapses every group that has ended.
+func (m *model) toggleAllGroups() {
+	t := m.tail()
+	if t == nil {
+		return
+	}
+
+	top := m.lineAt(m.viewport.YOffset)
+
+	anyCollapsed := false
+	for _, group := range t.groups {
+		anyCollapsed = anyCollapsed || group.collapsed
+	}
+
+	t.expandAll = anyCollapsed
+	for idx, group := range t.groups {
+		group.collapsed = !anyCollapsed && idx != t.openGroup
+	}
+	t.invalidate(0)
+
+	m.refreshContent()
+	if m.following {
+		m.viewport.GotoBottom()
+	} else {
+		m.viewport.SetYOffset(m.rowOf(top))
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
+)
+
+// actorMsg carries the login of whoever triggered a run, which go-github
+// doesn't include in the run itself.
+type actorMsg struct {
+	runId int64
+	actor string
+}
+
+var (
+	metaStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080")).Padding(0, 1)
+	badgeStyle = lipgloss.NewStyle().Bold(true).Padding(0, 1).Foreground(lipgloss.Color("#FFFFFF"))
+)
+
+func (m model) fetchActor(t *tailedRun) tea.Cmd {
+	if m.ghl == nil {
+		return nil
+	}
+
+	ctx, ghl, run := m.ctx, m.ghl, runOf(t.wfRun)
+	return func() tea.Msg {
+		actor, err := ghl.Actor(ctx, run)
+		if err != nil {
+			return nil // the header just goes without
+		}
+		return actorMsg{runId: run.RunId, actor: actor}
+	}
+}
+
+// metadataView describes what the run is of and where the job runs, e.g.
+// "1a2b3c4 Fix the build · main · push · @octocat · attempt 2 · runner-1 (ubuntu-latest)"
+func (m model) metadataView(t *tailedRun) string {
+	run := t.wfRun
+	parts := []string{}
+
+	if sha := run.GetHeadSHA(); len(sha) >= 7 {
+		parts = append(parts, strings.TrimSpace(sha[:7]+" "+commitTitle(run)))
+	}
+	parts = append(parts, run.GetHeadBranch(), run.GetEvent())
+
+	if t.actor != "" {
+		parts = append(parts, "@"+t.actor)
+	}
+	if attempt := run.GetRunAttempt(); attempt > 1 {
+		parts = append(parts, fmt.Sprintf("attempt %d", attempt))
+	}
+
+	if t.job != nil && t.job.GetRunnerName() != "" {
+		runner := t.job.GetRunnerName()
+		if len(t.job.Labels) > 0 {
+			runner += " (" + strings.Join(t.job.Labels, ", ") + ")"
+		}
+		parts = append(parts, runner)
+	}
+
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	text := truncate(strings.Join(nonEmpty, " · "), max(1, m.width-2))
+	return metaStyle.Render(text)
+}
+
+// badge is the status of the job, or the run if the job hasn't started yet,
+// coloured like the GitHub UI.
+func badge(t *tailedRun) string {
+	status, conclusion := t.wfRun.GetStatus(), t.wfRun.GetConclusion()
+	if t.job != nil {
+		status, conclusion = t.job.GetStatus(), t.job.GetConclusion()
+	}
+
+	color, text := "#808080", strings.ReplaceAll(status, "_", " ")
+	switch {
+	case status == "in_progress":
+		color = "#D7A000"
+	case status != "completed":
+	case conclusion == "success":
+		color, text = "#067D17", "✓ success"
+	case conclusion == "cancelled" || conclusion == "skipped":
+		text = "⊘ " + conclusion
+	default:
+		color, text = "#CC0000", "✗ "+strings.ReplaceAll(conclusion, "_", " ")
+	}
+
+	return badgeStyle.Copy().Background(lipgloss.Color(color)).Render(text)
+}
+
+// jobTimes returns how long the job waited for a runner, and how long it has
+// run for since. The job counts as running from when its first step started.
+func jobTimes(t *tailedRun) (time.Duration, time.Duration, bool) {
+	if t.job == nil || t.job.GetStartedAt().Time.IsZero() {
+		return 0, 0, false
+	}
+
+	queued := t.job.GetStartedAt().Time
+	end := time.Now()
+	if completed := t.job.GetCompletedAt().Time; !completed.IsZero() {
+		end = completed
+	}
+
+	var started time.Time
+	for _, step := range t.job.Steps {
+		if s := step.GetStartedAt().Time; !s.IsZero() && (started.IsZero() || s.Before(started)) {
+			started = s
+		}
+	}
+
+	if started.IsZero() {
+		return end.Sub(queued), 0, true
+	}
+
+	return started.Sub(queued), end.Sub(started), true
+}
+
+// elapsed is the time since the run started, frozen once the job (or, if it
+// never started, the run) has completed.
+func elapsed(t *tailedRun) time.Duration {
+	end := time.Now()
+	if t.job != nil && !t.job.GetCompletedAt().Time.IsZero() {
+		end = t.job.GetCompletedAt().Time
+	} else if t.completed() && !t.wfRun.GetUpdatedAt().Time.IsZero() {
+		end = t.wfRun.GetUpdatedAt().Time
+	}
+
+	return end.Sub(t.wfRun.GetRunStartedAt().Time).Truncate(time.Second)
+}
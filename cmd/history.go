@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/aidansteele/ghal/runs"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	historyHeight = 10
+	historyLimit  = 30
+)
+
+// history lists recent runs of the workflow, so that the logs of one that
+// has already finished can be loaded.
+type history struct {
+	panel
+	loading bool
+	runs    []*github.WorkflowRun
+
+	lister   runs.Lister
+	owner    string
+	repo     string
+	workflow string
+	filter   runs.Filter
+}
+
+func newHistory(lister runs.Lister, owner, repo, workflow string, filter runs.Filter) history {
+	return history{lister: lister, owner: owner, repo: repo, workflow: workflow, filter: filter}
+}
+
+// historyMsg carries the recent runs of the workflow.
+type historyMsg struct {
+	runs []*github.WorkflowRun
+	err  error
+}
+
+// historyLogsMsg carries the complete logs of a finished run's jobs.
+type historyLogsMsg struct {
+	run   *github.WorkflowRun
+	tails []*tailedRun
+	err   error
+}
+
+func (m *model) fetchHistory() tea.Cmd {
+	if m.history.lister == nil {
+		return nil
+	}
+
+	m.history.loading = true
+	ctx, h := m.ctx, m.history
+	return func() tea.Msg {
+		recent, err := runs.Recent(ctx, h.lister, h.owner, h.repo, h.workflow, h.filter, historyLimit)
+		return historyMsg{runs: recent, err: err}
+	}
+}
+
+func (m *model) setHistory(msg historyMsg) {
+	m.history.loading = false
+	if msg.err != nil {
+		m.notice = fmt.Sprintf("couldn't load runs: %s", errors.Cause(msg.err))
+		return
+	}
+
+	m.history.runs = msg.runs
+	m.history.cursor = max(0, min(len(msg.runs)-1, m.history.cursor))
+}
+
+func (m model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	list := m.history.runs
+	cmd := m.updatePanel(&m.history.panel, msg, "H", len(list), func(cursor int) tea.Cmd {
+		return m.openRun(list[cursor])
+	})
+	return m, cmd
+}
+
+// openRun shows a run from the history. A run that's being tailed is
+// switched to; otherwise the logs of a finished run are loaded.
+func (m *model) openRun(run *github.WorkflowRun) tea.Cmd {
+	for idx, t := range m.tails {
+		if *t.wfRun.ID != *run.ID {
+			continue
+		}
+
+		m.history.visible = false
+		m.resize()
+		if !m.showsElsewhere(t) {
+			m.switchTo(idx)
+		}
+		return nil
+	}
+
+	if run.GetStatus() != "completed" {
+		m.notice = fmt.Sprintf("run #%d hasn't finished yet", run.GetRunNumber())
+		return nil
+	}
+
+	m.history.visible = false
+	m.resize()
+	m.notice = fmt.Sprintf("loading run #%d…", run.GetRunNumber())
+
+	ctx, ghl, jobs, memoryCap := m.ctx, m.ghl, m.jobs, m.memoryCap
+	return func() tea.Msg {
+		loaded, err := loadJobs(ctx, ghl, run, jobs, memoryCap)
+		return historyLogsMsg{run: run, tails: loaded, err: err}
+	}
+}
+
+// loadJobs downloads the complete logs of each of the run's jobs that match,
+// each into an entry of its own.
+func loadJobs(ctx context.Context, ghl *ghlogs.Ghlogs, run *github.WorkflowRun, jobs jobPattern, memoryCap int) ([]*tailedRun, error) {
+	all, err := ghl.Jobs(ctx, runOf(run))
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := []*tailedRun{}
+	for _, job := range all {
+		if !jobs.matches(job.GetName()) || job.GetConclusion() == "skipped" {
+			continue
+		}
+
+		t := newTailedRun(run, memoryCap)
+		t.jobName = job.GetName()
+		t.job = job
+		t.archived = true
+		loaded = append(loaded, t)
+
+		err := readJobLogs(ctx, ghl, t)
+		if err != nil {
+			closeAll(loaded)
+			return nil, err
+		}
+	}
+
+	return loaded, nil
+}
+
+// readJobLogs streams a finished job's logs into its buffer, split into its
+// steps as if they had streamed in. The logs don't say which step each line
+// is from, so that's assumed from the line's timestamp and when each step
+// started.
+func readJobLogs(ctx context.Context, ghl *ghlogs.Ghlogs, t *tailedRun) error {
+	run := runOf(t.wfRun)
+	body, err := ghl.JobLogs(ctx, run, t.job.GetID())
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// unlike a bufio.Scanner, this has no limit on the length of a line
+	r := bufio.NewReader(body)
+
+	var step *github.TaskStep
+	for {
+		raw, readErr := r.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return errors.WithStack(readErr)
+		}
+		if raw == "" && readErr == io.EOF {
+			return nil
+		}
+
+		raw = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+		if ts := logfmt.Parse(raw).Timestamp; !ts.IsZero() {
+			step = stepAt(t.job, ts)
+		}
+
+		name, number := "?", 0
+		if step != nil {
+			name, number = step.GetName(), int(step.GetNumber())
+		}
+
+		// the filter is applied once the run is shown
+		err := t.buffer(ghlogs.RunOutput{
+			Run:             run,
+			JobName:         t.jobName,
+			JobId:           t.job.GetID(),
+			StepName:        name,
+			StepNumber:      number,
+			AssumedStepName: true,
+			Lines:           []string{raw},
+		}, lineFilter{}.hides)
+		if err != nil {
+			return err
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// addArchived adds an entry for each job of a run loaded from the history,
+// and shows the first of them.
+func (m *model) addArchived(msg historyLogsMsg) tea.Cmd {
+	m.notice = ""
+	if msg.err != nil {
+		m.notice = fmt.Sprintf("couldn't load run #%d: %s", msg.run.GetRunNumber(), errors.Cause(msg.err))
+		return nil
+	}
+
+	if len(msg.tails) == 0 {
+		m.notice = fmt.Sprintf("no job in run #%d matches %q", msg.run.GetRunNumber(), m.jobs)
+		return nil
+	}
+
+	// it may have been opened twice before it finished loading
+	for _, t := range m.tails {
+		if *t.wfRun.ID == *msg.run.ID {
+			closeAll(msg.tails)
+			return nil
+		}
+	}
+
+	first := len(m.tails)
+	cmds := []tea.Cmd{}
+	for _, t := range msg.tails {
+		if m.filter.active() {
+			t.applyFilter(m.filter)
+		}
+
+		m.tails = append(m.tails, t)
+		cmds = append(cmds, m.fetchActor(t))
+	}
+
+	m.switchTo(first)
+	return tea.Batch(cmds...)
+}
+
+// stepAt returns the last step to have started by the given time.
+func stepAt(job *github.WorkflowJob, ts time.Time) *github.TaskStep {
+	var at *github.TaskStep
+	for _, step := range job.Steps {
+		started := step.GetStartedAt().Time
+		if !started.IsZero() && !ts.Before(started.Truncate(time.Second)) {
+			at = step
+		}
+	}
+	return at
+}
+
+// goLive closes the runs loaded from the history, and goes back to following
+// the latest live run.
+func (m *model) goLive() {
+	cur := m.tail()
+
+	kept := []*tailedRun{}
+	for _, t := range m.tails {
+		if t.archived {
+			t.store.Close()
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	m.tails = kept
+	m.closeStalePanes()
+
+	if cur == nil || !cur.archived {
+		m.follow()
+		return
+	}
+
+	for idx := len(m.tails) - 1; idx >= 0; idx-- {
+		if !m.showsElsewhere(m.tails[idx]) {
+			m.switchTo(idx)
+			return
+		}
+	}
+
+	if m.split != splitNone {
+		m.closePane()
+		m.follow()
+		return
+	}
+
+	m.switchTo(0)
+}
+
+// historyViewHeight is how many rows the panel takes up, if it's visible.
+func (m model) historyViewHeight() int {
+	if !m.history.visible {
+		return 0
+	}
+	return historyHeight + 1 // the border
+}
+
+func (m model) historyView() string {
+	lines := []string{}
+	for _, run := range m.history.runs {
+		icon, style := statusIcon(run.GetStatus(), run.GetConclusion())
+
+		where := ""
+		for _, t := range m.tails {
+			if *t.wfRun.ID == *run.ID {
+				where = " (following)"
+				if t.archived {
+					where = " (loaded)"
+				}
+			}
+		}
+
+		prefix := fmt.Sprintf("%s #%-5d %-20s %4s  ", style.Render(icon), run.GetRunNumber(), truncate(run.GetHeadBranch(), 20), age(run.GetCreatedAt().Time))
+		title := truncate(commitTitle(run)+where, max(1, m.width-lipgloss.Width(prefix)))
+		lines = append(lines, prefix+title)
+	}
+
+	placeholder := dimStyle.Render("no runs")
+	if m.history.loading {
+		placeholder = dimStyle.Render("loading runs…")
+	}
+
+	view := m.history.view(lines, placeholder, historyHeight)
+	return annotationsStyle.Width(m.width).Height(historyHeight).MaxHeight(historyHeight + 1).Render(view)
+}
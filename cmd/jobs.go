@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// jobPattern is the job argument of tail. It's a job name, a glob like
+// `build*` or a regex between slashes like `/^deploy \/ (plan|apply)$/`. Each
+// is also tried against the base name of matrix jobs, so `build` matches
+// `build (ubuntu-latest, 1.18)`.
+type jobPattern struct {
+	text string
+	re   *regexp.Regexp // nil for a plain name
+}
+
+func parseJobPattern(text string) (jobPattern, error) {
+	p := jobPattern{text: text}
+
+	switch {
+	case len(text) > 2 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/"):
+		re, err := regexp.Compile(text[1 : len(text)-1])
+		if err != nil {
+			return p, errors.Errorf("invalid job regex %q: %s", text, err)
+		}
+		p.re = re
+	case strings.ContainsAny(text, "*?["):
+		re, err := regexp.Compile(globRegexp(text))
+		if err != nil {
+			return p, errors.Errorf("invalid job glob %q: %s", text, err)
+		}
+		p.re = re
+	}
+
+	return p, nil
+}
+
+// globRegexp translates a glob into an anchored regex. Unlike path.Match, `*`
+// also matches slashes, which separate the names of called workflows and
+// their jobs.
+func globRegexp(glob string) string {
+	sb := &strings.Builder{}
+	sb.WriteString("^")
+
+	for idx := 0; idx < len(glob); idx++ {
+		switch c := glob[idx]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(glob[idx:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[idx+1 : idx+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			idx += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// matches reports whether a job is one of those being tailed.
+func (p jobPattern) matches(name string) bool {
+	return p.matchesName(name) || p.matchesName(matrixBaseName(name))
+}
+
+// matchesName reports whether name matches the pattern. A name always
+// matches itself, so that jobs like `deploy [prod]` can still be tailed by
+// name even though they look like globs.
+func (p jobPattern) matchesName(name string) bool {
+	if name == p.text {
+		return true
+	}
+	return p.re != nil && p.re.MatchString(name)
+}
+
+func (p jobPattern) String() string {
+	return p.text
+}
+
+// matrixBaseName strips the matrix values that GitHub appends to the names of
+// matrix jobs, e.g. `build (ubuntu-latest, 1.18)` is `build`.
+func matrixBaseName(name string) string {
+	if idx := strings.LastIndex(name, " ("); idx > 0 && strings.HasSuffix(name, ")") {
+		return name[:idx]
+	}
+	return name
+}
+
+// jobKey identifies a job within the runs being tailed, for renderers that
+// keep track of each job they've printed.
+func jobKey(runId int64, jobName string) string {
+	return fmt.Sprintf("%d/%s", runId, jobName)
+}
+
+// forgetRun deletes what was kept about every job of a run, e.g. when a new
+// attempt starts.
+func forgetRun[V any](m map[string]V, runId int64) {
+	for key := range m {
+		if strings.HasPrefix(key, jobKey(runId, "")) {
+			delete(m, key)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"io"
+	"time"
+)
+
+// jsonlRecord is a line of `--output jsonl`. Type is "line" for a line of
+// output, or one of job_started, job_completed, step_started and
+// step_completed. Fields that are pointers are always present on line
+// records, even when zero.
+type jsonlRecord struct {
+	Type            string     `json:"type"`
+	Owner           string     `json:"owner"`
+	Repo            string     `json:"repo"`
+	RunId           int64      `json:"run_id"`
+	RunAttempt      int        `json:"run_attempt"`
+	JobName         string     `json:"job_name"`
+	JobId           int64      `json:"job_id,omitempty"`
+	StepName        string     `json:"step_name,omitempty"`
+	StepNumber      *int       `json:"step_number,omitempty"`
+	AssumedStepName *bool      `json:"assumed_step_name,omitempty"`
+	LineNumber      *int       `json:"line_number,omitempty"`
+	Timestamp       *time.Time `json:"timestamp,omitempty"`
+	Kind            string     `json:"kind,omitempty"`
+	Text            *string    `json:"text,omitempty"`
+	Conclusion      string     `json:"conclusion,omitempty"`
+}
+
+// jsonlRun is a tailed run, and what's been written so far about each of its
+// matching jobs.
+type jsonlRun struct {
+	run  *github.WorkflowRun
+	jobs map[string]*jsonlJob
+}
+
+type jsonlJob struct {
+	name         string
+	lines        int
+	status       string
+	stepsStarted map[int]bool
+	stepsDone    map[int]bool
+}
+
+// job returns the state of one of the run's jobs, starting it if need be.
+func (jr *jsonlRun) job(name string) *jsonlJob {
+	jj := jr.jobs[name]
+	if jj == nil {
+		jj = &jsonlJob{name: name, stepsStarted: map[int]bool{}, stepsDone: map[int]bool{}}
+		jr.jobs[name] = jj
+	}
+	return jj
+}
+
+type jsonlRenderer struct {
+	enc    *json.Encoder
+	jobs   jobPattern
+	filter lineFilter
+	runs   map[int64]*jsonlRun
+}
+
+func jsonlOutput(ctx context.Context, s streams, jobs jobPattern, filter lineFilter, w io.Writer) error {
+	r := &jsonlRenderer{
+		enc:    json.NewEncoder(w),
+		jobs:   jobs,
+		filter: filter,
+		runs:   map[int64]*jsonlRun{},
+	}
+
+	for {
+		var err error
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err = <-s.errs:
+		case run := <-s.tailed:
+			r.runs[*run.ID] = &jsonlRun{run: run, jobs: map[string]*jsonlJob{}}
+		case output := <-s.output:
+			err = r.lines(output)
+		case update := <-s.updates:
+			err = r.update(update)
+		case <-s.transitions:
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// record starts a record with the fields common to every type.
+func (r *jsonlRenderer) record(typ string, jr *jsonlRun, jj *jsonlJob) jsonlRecord {
+	return jsonlRecord{
+		Type:       typ,
+		Owner:      jr.run.GetRepository().GetOwner().GetLogin(),
+		Repo:       jr.run.GetRepository().GetName(),
+		RunId:      jr.run.GetID(),
+		RunAttempt: jr.run.GetRunAttempt(),
+		JobName:    jj.name,
+	}
+}
+
+func (r *jsonlRenderer) lines(output ghlogs.RunOutput) error {
+	jr := r.runs[output.Run.RunId]
+	if jr == nil || !r.jobs.matches(output.JobName) {
+		return nil
+	}
+	jj := jr.job(output.JobName)
+	stepNumber, assumed := output.StepNumber, output.AssumedStepName
+
+	// the first line of a step usually arrives before the API says it started
+	if output.StepNumber != 0 && !jj.stepsStarted[output.StepNumber] {
+		jj.stepsStarted[output.StepNumber] = true
+		rec := r.record("step_started", jr, jj)
+		rec.JobId = output.JobId
+		rec.StepName = output.StepName
+		rec.StepNumber = &stepNumber
+		rec.AssumedStepName = &assumed
+		if err := r.enc.Encode(rec); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	for _, raw := range output.Lines {
+		line := logfmt.Parse(raw)
+		jj.lines++ // filtered lines are still counted, so line numbers stay the same
+		if r.filter.hides(line) {
+			continue
+		}
+
+		rec := r.record("line", jr, jj)
+		rec.JobId = output.JobId
+		lineNumber := jj.lines
+		rec.StepName = output.StepName
+		rec.StepNumber = &stepNumber
+		rec.AssumedStepName = &assumed
+		rec.LineNumber = &lineNumber
+		rec.Kind = line.Kind.String()
+		rec.Text = &line.Text
+		if !line.Timestamp.IsZero() {
+			rec.Timestamp = &line.Timestamp
+		}
+
+		if err := r.enc.Encode(rec); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// update writes lifecycle records for the job and its steps.
+func (r *jsonlRenderer) update(update ghlogs.RunUpdate) error {
+	jr := r.runs[update.Run.RunId]
+	if jr == nil {
+		return nil
+	}
+	jr.run = update.WorkflowRun
+
+	for _, status := range update.Jobs {
+		job := status.Job
+		if !r.jobs.matches(job.GetName()) {
+			continue
+		}
+		jj := jr.job(job.GetName())
+
+		records := []jsonlRecord{}
+		if jj.status == "" && job.GetStatus() != "queued" {
+			jj.status = "in_progress"
+			rec := r.record("job_started", jr, jj)
+			rec.JobId = job.GetID()
+			rec.Timestamp = timePtr(job.GetStartedAt())
+			records = append(records, rec)
+		}
+
+		for _, step := range job.Steps {
+			number := int(step.GetNumber())
+			if step.GetStatus() != "queued" && !jj.stepsStarted[number] {
+				jj.stepsStarted[number] = true
+				rec := r.record("step_started", jr, jj)
+				rec.JobId = job.GetID()
+				rec.StepName = step.GetName()
+				rec.StepNumber = &number
+				rec.Timestamp = timePtr(step.GetStartedAt())
+				records = append(records, rec)
+			}
+
+			if step.GetStatus() == "completed" && !jj.stepsDone[number] {
+				jj.stepsDone[number] = true
+				rec := r.record("step_completed", jr, jj)
+				rec.JobId = job.GetID()
+				rec.StepName = step.GetName()
+				rec.StepNumber = &number
+				rec.Timestamp = timePtr(step.GetCompletedAt())
+				rec.Conclusion = step.GetConclusion()
+				records = append(records, rec)
+			}
+		}
+
+		if jj.status != "completed" && job.GetStatus() == "completed" {
+			jj.status = "completed"
+			rec := r.record("job_completed", jr, jj)
+			rec.JobId = job.GetID()
+			rec.Timestamp = timePtr(job.GetCompletedAt())
+			rec.Conclusion = job.GetConclusion()
+			records = append(records, rec)
+		}
+
+		for _, rec := range records {
+			if err := r.enc.Encode(rec); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func timePtr(ts github.Timestamp) *time.Time {
+	if ts.Time.IsZero() {
+		return nil
+	}
+	return &ts.Time
+}
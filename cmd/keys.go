@@ -0,0 +1,142 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// defaultKeys are the TUI's actions and the keys they're bound to, which the
+// `keys` section of the config file can change.
+var defaultKeys = map[string]string{
+	"quit":              "q",
+	"next-run":          "tab",
+	"previous-run":      "shift+tab",
+	"dismiss":           "x",
+	"steps":             "s",
+	"annotations":       "a",
+	"filter":            "f",
+	"search":            "/",
+	"search-backward":   "?",
+	"next-match":        "n",
+	"previous-match":    "N",
+	"next-group":        "]",
+	"previous-group":    "[",
+	"toggle-group":      "enter",
+	"toggle-all-groups": "e",
+	"follow":            "G",
+	"top":               "g",
+	"timestamps":        "t",
+	"save":              "w",
+	"save-step":         "W",
+	"copy":              "y",
+	"copy-step":         "Y",
+	"cancel":            "C",
+	"rerun":             "R",
+	"rerun-failed":      "F",
+	"open":              "o",
+	"open-job":          "O",
+	"history":           "H",
+	"live":              "L",
+	"split-vertical":    "|",
+	"split-horizontal":  "-",
+	"next-pane":         "p",
+	"close-pane":        "c",
+	"grow-pane":         ">",
+	"shrink-pane":       "<",
+	"scroll-down":       "j",
+	"scroll-up":         "k",
+	"half-page-down":    "d",
+	"half-page-up":      "u",
+	"page-down":         " ",
+	"page-up":           "b",
+}
+
+// fixedKeys always do the same thing, so no action can be bound to them.
+var fixedKeys = map[string]string{
+	"ctrl+c": "quits",
+	"esc":    "closes panels",
+	"up":     "scrolls up",
+	"down":   "scrolls down",
+	"pgup":   "pages up",
+	"pgdown": "pages down",
+	"ctrl+u": "scrolls up half a page",
+	"ctrl+d": "scrolls down half a page",
+	"home":   "goes to the top",
+	"end":    "follows new output",
+}
+
+// keymap translates the keys that have been rebound into the default key
+// for the same action. A default key whose action has been bound to another
+// key does nothing, unless it's now used for something else.
+type keymap map[string]string
+
+func newKeymap(bindings map[string]string) (keymap, error) {
+	km := keymap{}
+
+	actions := make([]string, 0, len(bindings))
+	for action, key := range bindings {
+		if _, ok := defaultKeys[action]; !ok {
+			return nil, errors.Errorf("unknown action %q in key bindings, expected one of: %s", action, strings.Join(keyActions(), ", "))
+		}
+		if key == "" {
+			return nil, errors.Errorf("no key given for action %q in key bindings", action)
+		}
+		if does, ok := fixedKeys[key]; ok {
+			return nil, errors.Errorf("key %q can't be bound to %q, it always %s", key, action, does)
+		}
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	// every action, bound to its key once the bindings have been applied
+	byKey := map[string]string{}
+	for _, action := range keyActions() {
+		key := defaultKeys[action]
+		if bound, ok := bindings[action]; ok {
+			key = bound
+		}
+
+		if other, ok := byKey[key]; ok {
+			return nil, errors.Errorf("key %q is bound to both %q and %q", key, other, action)
+		}
+		byKey[key] = action
+	}
+
+	for _, action := range actions {
+		km[defaultKeys[action]] = ""
+	}
+
+	for _, action := range actions {
+		km[bindings[action]] = defaultKeys[action]
+	}
+
+	return km, nil
+}
+
+func (km keymap) translate(key string) string {
+	if translated, ok := km[key]; ok {
+		return translated
+	}
+	return key
+}
+
+// translatePanel is translate for a panel that has the keyboard focus. Its
+// own keys for moving around and choosing an item are never rebound.
+func (km keymap) translatePanel(key string) string {
+	switch key {
+	case "ctrl+c", "up", "k", "down", "j", "enter", "esc":
+		return key
+	default:
+		return km.translate(key)
+	}
+}
+
+func keyActions() []string {
+	actions := make([]string, 0, len(defaultKeys))
+	for action := range defaultKeys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
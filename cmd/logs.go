@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+type logsOptions struct {
+	*globalOptions
+	runId int64
+}
+
+func newLogsCmd(g *globalOptions) *cobra.Command {
+	opts := &logsOptions{globalOptions: g}
+
+	cmd := &cobra.Command{
+		Use:   "logs <workflow file> <job name>",
+		Short: "Print the complete logs of a job from a finished run",
+		Example: `  # print the logs of the "build" job from the latest ci.yml run
+  ghal logs ci.yml build
+
+  # ...or from a specific run
+  ghal logs ci.yml build --run 2219087364`,
+		Args: namedArgs(2, "workflow file", "job name"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().Int64Var(&opts.runId, "run", 0, "run ID (default: the latest run)")
+
+	return cmd
+}
+
+func (opts *logsOptions) run(ctx context.Context, workflowFileName, jobName string) error {
+	_, err := opts.outputFormat("text")
+	if err != nil {
+		return err
+	}
+
+	sess, err := opts.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	runId := opts.runId
+	if runId == 0 {
+		recent, err := runs.Recent(ctx, sess.api.Actions, sess.repo.Owner, sess.repo.Repo, workflowFileName, opts.filter(), 1)
+		if err != nil {
+			return err
+		}
+
+		if len(recent) == 0 {
+			return errors.Errorf("no runs found for workflow %s", workflowFileName)
+		}
+
+		runId = *recent[0].ID
+	}
+
+	ghl := ghlogs.New(sess.api, sess.client, os.Getenv("GITHUB_USER_SESSION"))
+	run := ghlogs.Run{Owner: sess.repo.Owner, Repo: sess.repo.Repo, RunId: runId}
+
+	job, err := ghl.FindJob(ctx, run, jobName)
+	if err != nil {
+		return err
+	}
+
+	if *job.Status != "completed" {
+		return errors.Errorf("job %q in run %d hasn't finished yet, use `ghal tail` to follow it live", jobName, runId)
+	}
+
+	body, err := ghl.JobLogs(ctx, run, *job.ID)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = io.Copy(os.Stdout, body)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// logView is a viewport over the rows of a log. Unlike the bubbles viewport
+// it doesn't hold the content, so only the rows in view are ever rendered -
+// which matters once a job has logged a few hundred thousand lines. It has the
+// same keys as the bubbles viewport, less those that the TUI uses for
+// something else.
+type logView struct {
+	Width   int
+	Height  int
+	YOffset int
+	rows    int
+}
+
+func (v logView) maxYOffset() int {
+	return max(0, v.rows-v.Height)
+}
+
+// SetRows sets how many rows there are to scroll through.
+func (v *logView) SetRows(n int) {
+	v.rows = n
+	v.SetYOffset(v.YOffset)
+}
+
+func (v *logView) SetYOffset(n int) {
+	v.YOffset = max(0, min(n, v.maxYOffset()))
+}
+
+func (v *logView) GotoTop() {
+	v.YOffset = 0
+}
+
+func (v *logView) GotoBottom() {
+	v.YOffset = v.maxYOffset()
+}
+
+func (v logView) AtBottom() bool {
+	return v.YOffset >= v.maxYOffset()
+}
+
+// scroll moves the view for a key, which has already been translated by the
+// keymap.
+func (v *logView) scroll(key string) {
+	switch key {
+	case "pgdown", " ":
+		v.SetYOffset(v.YOffset + v.Height)
+	case "pgup", "b":
+		v.SetYOffset(v.YOffset - v.Height)
+	case "ctrl+d", "d":
+		v.SetYOffset(v.YOffset + v.Height/2)
+	case "ctrl+u", "u":
+		v.SetYOffset(v.YOffset - v.Height/2)
+	case "down", "j":
+		v.SetYOffset(v.YOffset + 1)
+	case "up", "k":
+		v.SetYOffset(v.YOffset - 1)
+	}
+}
+
+func (v logView) Update(msg tea.Msg) (logView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		switch msg.Type {
+		case tea.MouseWheelDown:
+			v.SetYOffset(v.YOffset + 3)
+		case tea.MouseWheelUp:
+			v.SetYOffset(v.YOffset - 3)
+		}
+	}
+
+	return v, nil
+}
+
+// View renders the rows in view with render, padded to the full height.
+func (v logView) View(render func(row int) string) string {
+	lines := make([]string, v.Height)
+	for idx := range lines {
+		if row := v.YOffset + idx; row < v.rows {
+			lines[idx] = render(row)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
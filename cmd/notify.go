@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var (
+	notifyMethods = []string{"bell", "osc9", "osc777", "title"}
+	notifyEvents  = []string{"job", "run", "error"}
+)
+
+// notifier alerts the user when the tailed job or run concludes, or when the
+// job logs its first error, so that they can get on with something else in
+// the meantime.
+type notifier struct {
+	jobs    jobPattern
+	methods []string
+	events  []string
+	cmd     string
+
+	// terminal is where the TUI takes escape sequences from, as it can't have
+	// them written underneath it. Otherwise they're written straight away.
+	terminal chan string
+
+	lock     sync.Mutex
+	tailed   map[int64]*github.WorkflowRun
+	jobURLs  map[string]string // by jobKey
+	notified map[string]bool   // events that have already been notified, per run attempt
+}
+
+func newNotifier(opts *tailOptions) (*notifier, error) {
+	for _, method := range opts.notify {
+		if !contains(notifyMethods, method) {
+			return nil, errors.Errorf("unsupported notification %q, expected one of: %s", method, strings.Join(notifyMethods, ", "))
+		}
+	}
+
+	for _, event := range opts.notifyOn {
+		if !contains(notifyEvents, event) {
+			return nil, errors.Errorf("unsupported notification event %q, expected one of: %s", event, strings.Join(notifyEvents, ", "))
+		}
+	}
+
+	return &notifier{
+		methods:  opts.notify,
+		events:   opts.notifyOn,
+		cmd:      opts.notifyCmd,
+		tailed:   map[int64]*github.WorkflowRun{},
+		jobURLs:  map[string]string{},
+		notified: map[string]bool{},
+	}, nil
+}
+
+func contains(s []string, v string) bool {
+	for _, candidate := range s {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled reports whether anything should happen at all.
+func (n *notifier) enabled() bool {
+	return len(n.events) > 0 && (len(n.methods) > 0 || n.cmd != "")
+}
+
+// wrap returns streams that carry the same messages as s, after the notifier
+// has seen them.
+func (n *notifier) wrap(ctx context.Context, s streams, jobs jobPattern) streams {
+	if !n.enabled() {
+		return s
+	}
+
+	n.jobs = jobs
+
+	wrapped := newStreams()
+	wrapped.reruns = s.reruns
+	wrapped.terminal = s.terminal
+	wrapped.errs = s.errs
+
+	go n.forward(ctx, s, wrapped)
+	return wrapped
+}
+
+// forward passes each message on once the notifier has seen it. It's a single
+// goroutine so that messages keep their order, e.g. a run before its output.
+func (n *notifier) forward(ctx context.Context, in, out streams) {
+	for {
+		ok := false
+		select {
+		case <-ctx.Done():
+			return
+		case run := <-in.tailed:
+			n.started(run)
+			ok = send(ctx, out.tailed, run)
+		case output := <-in.output:
+			n.output(ctx, output)
+			ok = send(ctx, out.output, output)
+		case update := <-in.updates:
+			n.update(ctx, update)
+			ok = send(ctx, out.updates, update)
+		case transition := <-in.transitions:
+			n.transition(ctx, transition)
+			ok = send(ctx, out.transitions, transition)
+		}
+
+		if !ok {
+			return
+		}
+	}
+}
+
+// send reports whether msg was sent before ctx was done.
+func send[T any](ctx context.Context, ch chan T, msg T) bool {
+	select {
+	case ch <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (n *notifier) started(run *github.WorkflowRun) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.tailed[*run.ID] = run
+}
+
+// once reports whether an event hasn't been notified yet for the current
+// attempt of a run, and records that it now has been.
+func (n *notifier) once(event string, run *github.WorkflowRun) bool {
+	key := fmt.Sprintf("%s/%d/%d", event, run.GetID(), run.GetRunAttempt())
+	if n.notified[key] {
+		return false
+	}
+	n.notified[key] = true
+	return true
+}
+
+func (n *notifier) output(ctx context.Context, output ghlogs.RunOutput) {
+	if !contains(n.events, "error") || !n.jobs.matches(output.JobName) {
+		return
+	}
+
+	n.lock.Lock()
+	run := n.tailed[output.Run.RunId]
+	if run == nil {
+		n.lock.Unlock()
+		return
+	}
+
+	message := ""
+	for _, raw := range output.Lines {
+		if line := logfmt.Parse(raw); line.Kind == logfmt.KindError {
+			message = line.Text
+			break
+		}
+	}
+
+	notify := message != "" && n.once("error "+output.JobName, run)
+	url := n.jobURLs[jobKey(*run.ID, output.JobName)]
+	n.lock.Unlock()
+
+	if notify {
+		title := fmt.Sprintf("%s #%d: error", output.JobName, run.GetRunNumber())
+		n.notify(ctx, "error", title, message, "failure", url)
+	}
+}
+
+func (n *notifier) update(ctx context.Context, update ghlogs.RunUpdate) {
+	n.lock.Lock()
+	run := n.tailed[update.Run.RunId]
+	if run == nil {
+		n.lock.Unlock()
+		return
+	}
+
+	completed := []*github.WorkflowJob{}
+	for _, status := range update.Jobs {
+		job := status.Job
+		if !n.jobs.matches(job.GetName()) {
+			continue
+		}
+
+		n.jobURLs[jobKey(*run.ID, job.GetName())] = job.GetHTMLURL()
+		if contains(n.events, "job") && job.GetStatus() == "completed" && n.once("job "+job.GetName(), update.WorkflowRun) {
+			completed = append(completed, job)
+		}
+	}
+	n.lock.Unlock()
+
+	for _, job := range completed {
+		title := fmt.Sprintf("%s #%d: %s", job.GetName(), run.GetRunNumber(), job.GetConclusion())
+		n.notify(ctx, "job", title, run.GetName(), job.GetConclusion(), job.GetHTMLURL())
+	}
+}
+
+func (n *notifier) transition(ctx context.Context, transition runs.Transition) {
+	n.lock.Lock()
+	_, tailed := n.tailed[*transition.Run.ID]
+	notify := tailed && contains(n.events, "run") && transition.To == "completed" && n.once("run", transition.Run)
+	n.lock.Unlock()
+
+	if notify {
+		run := transition.Run
+		title := fmt.Sprintf("%s #%d: %s", run.GetName(), run.GetRunNumber(), transition.Conclusion)
+		n.notify(ctx, "run", title, commitTitle(run), transition.Conclusion, run.GetHTMLURL())
+	}
+}
+
+// notify alerts the user in every way they asked for. Escape sequences are
+// handed to the TUI, or written to whichever of stdout or stderr is a
+// terminal, so that they don't end up in piped output.
+func (n *notifier) notify(ctx context.Context, event, title, body, conclusion, url string) {
+	for _, method := range n.methods {
+		switch method {
+		case "bell":
+			n.write(ctx, "\a")
+		case "osc9":
+			n.write(ctx, terminalSeq(fmt.Sprintf("\x1b]9;%s\a", title)))
+		case "osc777":
+			n.write(ctx, terminalSeq(fmt.Sprintf("\x1b]777;notify;%s;%s\a", title, body)))
+		case "title":
+			n.write(ctx, terminalSeq(fmt.Sprintf("\x1b]2;%s\a", title)))
+		}
+	}
+
+	if n.cmd == "" {
+		return
+	}
+
+	// the hook's output would corrupt the TUI, so it's discarded
+	cmd := exec.Command("sh", "-c", n.cmd)
+	cmd.Env = append(os.Environ(),
+		"GHAL_EVENT="+event,
+		"GHAL_TITLE="+title,
+		"GHAL_MESSAGE="+body,
+		"GHAL_CONCLUSION="+conclusion,
+		"GHAL_URL="+url,
+	)
+	go cmd.Run()
+}
+
+// notificationMsg is an escape sequence from the notifier, for the TUI to
+// write.
+type notificationMsg string
+
+func (n *notifier) write(ctx context.Context, seq string) {
+	if n.terminal != nil {
+		send(ctx, n.terminal, seq)
+		return
+	}
+
+	if w := terminalWriter(); w != nil {
+		io.WriteString(w, seq)
+	}
+}
+
+func terminalWriter() io.Writer {
+	switch {
+	case term.IsTerminal(int(os.Stdout.Fd())):
+		return os.Stdout
+	case term.IsTerminal(int(os.Stderr.Fd())):
+		return os.Stderr
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+)
+
+// panel is a list beside or below the logs: the sidebar, the annotations and
+// the history. While it is visible it has the keyboard focus, so that the
+// cursor keys select an item rather than scroll.
+type panel struct {
+	visible bool
+	cursor  int
+}
+
+var panelCursorStyle = lipgloss.NewStyle().Reverse(true)
+
+func (p *panel) open(cursor int) {
+	p.visible = true
+	p.cursor = cursor
+}
+
+// updatePanel handles a key while p has the focus, for a list of n items.
+// toggle is the default key that opens and closes the panel, and choose is
+// called with the cursor when enter is pressed.
+func (m *model) updatePanel(p *panel, msg tea.KeyMsg, toggle string, n int, choose func(cursor int) tea.Cmd) tea.Cmd {
+	switch m.keys.translatePanel(msg.String()) {
+	case "ctrl+c", "q":
+		return tea.Quit
+	case toggle, "esc":
+		p.visible = false
+		m.resize()
+	case "up", "k":
+		p.cursor = max(0, p.cursor-1)
+	case "down", "j":
+		p.cursor = max(0, min(n-1, p.cursor+1))
+	case "enter":
+		if p.cursor < n {
+			return choose(p.cursor)
+		}
+	}
+
+	return nil
+}
+
+// view renders the lines of the items, or the placeholder if there are none.
+// The cursor's line is highlighted, and kept in view when there are more
+// items than fit in height.
+func (p panel) view(lines []string, placeholder string, height int) string {
+	if len(lines) == 0 {
+		return placeholder
+	}
+
+	if p.cursor < len(lines) {
+		lines[p.cursor] = panelCursorStyle.Render(lines[p.cursor])
+	}
+
+	if start := p.cursor - height + 1; start > 0 {
+		lines = lines[start:]
+	}
+
+	return strings.Join(lines, "\n")
+}
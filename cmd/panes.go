@@ -0,0 +1,291 @@
+package main
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+)
+
+// A pane is a viewport onto one of the tailed jobs. The model's own pane is
+// the one with focus; when the screen is split the others are kept in
+// model.panes, and each scrolls and follows output on its own.
+type pane struct {
+	shown     *tailedRun
+	viewport  logView
+	rows      []int      // the line shown in each row of the viewport
+	laidOut   *tailedRun // the run that rows belongs to
+	following bool
+	unseen    int // rows added since we stopped following
+	size      int // relative to the other panes
+}
+
+type layout int
+
+const (
+	splitNone       layout = iota
+	splitVertical          // side by side
+	splitHorizontal        // one above the other
+)
+
+const (
+	maxPanes        = 4
+	defaultPaneSize = 4
+)
+
+var (
+	inactiveTitleStyle = titleStyle.Copy().Foreground(lipgloss.Color("#808080"))
+	dividerStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+)
+
+// eachPane calls fn with the model as it's seen by each pane: with that
+// pane's view state, sized to its share of the screen. Only the focused pane
+// gets the model itself, so anything else that fn changes for the others is
+// discarded.
+func (m *model) eachPane(fn func(p *model)) {
+	if m.split == splitNone {
+		fn(m)
+		return
+	}
+
+	m.panes[m.focus] = m.pane
+	width, height := m.width, m.height
+	widths, heights := m.paneSizes()
+
+	for idx := range m.panes {
+		p := m
+		if idx != m.focus {
+			unfocused := *m
+			unfocused.pane = m.panes[idx]
+			unfocused.inactive = true
+			unfocused.notice, unfocused.confirm = "", nil
+			unfocused.search = newSearch()
+			unfocused.filterBar = newFilterBar()
+			unfocused.sidebar.visible, unfocused.annotations.visible, unfocused.history.visible = false, false, false
+			p = &unfocused
+		}
+
+		p.width, p.height = widths[idx], heights[idx]
+		fn(p)
+		p.width, p.height = width, height
+		m.panes[idx] = p.pane
+	}
+
+	m.pane = m.panes[m.focus]
+}
+
+// paneSizes shares out the screen between the panes, in proportion to their
+// sizes. Side by side panes are separated by a one column divider.
+func (m model) paneSizes() ([]int, []int) {
+	sizes := make([]int, len(m.panes))
+	for idx, p := range m.panes {
+		sizes[idx] = p.size
+	}
+
+	widths, heights := make([]int, len(m.panes)), make([]int, len(m.panes))
+	if m.split == splitVertical {
+		widths = share(m.width-len(m.panes)+1, sizes)
+		for idx := range heights {
+			heights[idx] = m.height
+		}
+	} else {
+		heights = share(m.height, sizes)
+		for idx := range widths {
+			widths[idx] = m.width
+		}
+	}
+
+	return widths, heights
+}
+
+// share divides total in proportion to weights, with any remainder going to
+// the last.
+func share(total int, weights []int) []int {
+	sum := 0
+	for _, weight := range weights {
+		sum += weight
+	}
+
+	shares := make([]int, len(weights))
+	left := max(0, total)
+	for idx, weight := range weights {
+		shares[idx] = max(0, total) * weight / sum
+		if idx == len(weights)-1 {
+			shares[idx] = left
+		}
+		left -= shares[idx]
+	}
+	return shares
+}
+
+func (m model) splitView() string {
+	views := []string{}
+	m.eachPane(func(p *model) {
+		clip := lipgloss.NewStyle().MaxWidth(p.width).MaxHeight(p.height)
+		views = append(views, clip.Render(p.paneView()))
+	})
+
+	if m.split == splitHorizontal {
+		return lipgloss.JoinVertical(lipgloss.Left, views...)
+	}
+
+	divider := dividerStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", m.height), "\n"))
+	joined := []string{}
+	for idx, view := range views {
+		if idx > 0 {
+			joined = append(joined, divider)
+		}
+		joined = append(joined, view)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, joined...)
+}
+
+// splitPane opens a new pane on the next job that isn't shown yet, or
+// changes the direction of the split.
+func (m *model) splitPane(direction layout) {
+	if m.split != splitNone && m.split != direction {
+		m.split = direction
+		m.resize()
+		return
+	}
+
+	if len(m.panes) >= maxPanes {
+		m.notice = "there can be at most 4 panes"
+		return
+	}
+
+	var next *tailedRun
+	for _, t := range m.tails {
+		if !m.shows(t) {
+			next = t
+			break
+		}
+	}
+
+	if next == nil {
+		m.notice = "every job is already shown (tail more with a job pattern or --concurrent)"
+		return
+	}
+
+	if m.split == splitNone {
+		m.panes = []pane{m.pane}
+		m.focus = 0
+	}
+
+	m.split = direction
+	m.panes = append(m.panes, pane{following: true, size: defaultPaneSize})
+	m.focusPane(len(m.panes) - 1)
+	m.switchTo(indexOf(m.tails, next))
+}
+
+// focusPane moves the focus to another pane. The search only applies to the
+// focused pane, so it's run again over that pane's job.
+func (m *model) focusPane(idx int) {
+	if m.split == splitNone {
+		return
+	}
+
+	m.panes[m.focus] = m.pane
+	m.focus = (idx + len(m.panes)) % len(m.panes)
+	m.pane = m.panes[m.focus]
+
+	m.search.matches = nil
+	m.search.current = -1
+	if t := m.tail(); t != nil {
+		m.search.scan(t, 0)
+	}
+}
+
+// closePane closes the focused pane, and goes back to a single pane when
+// there's only one left.
+func (m *model) closePane() {
+	if m.split == splitNone {
+		return
+	}
+
+	closed := m.focus
+	m.panes = append(m.panes[:closed], m.panes[closed+1:]...)
+	m.focus = min(closed, len(m.panes)-1)
+	m.pane = m.panes[m.focus]
+	m.focusPane(m.focus)
+
+	if len(m.panes) == 1 {
+		m.unsplit()
+	}
+	m.resize()
+}
+
+// unsplit goes back to showing only the focused pane.
+func (m *model) unsplit() {
+	m.split = splitNone
+	m.panes = nil
+	m.focus = 0
+	m.resize()
+}
+
+// closeStalePanes closes panes whose job has gone, e.g. because the run has
+// been re-run.
+func (m *model) closeStalePanes() {
+	if m.split == splitNone {
+		return
+	}
+
+	m.panes[m.focus] = m.pane
+	focused := m.shown
+
+	kept := []pane{}
+	for _, p := range m.panes {
+		if p.shown == focused || indexOf(m.tails, p.shown) >= 0 {
+			kept = append(kept, p)
+		}
+	}
+
+	m.panes = kept
+	for idx, p := range kept {
+		if p.shown == focused {
+			m.focus = idx
+		}
+	}
+
+	if len(m.panes) == 1 {
+		m.unsplit()
+	}
+	m.resize()
+}
+
+// resizePane grows or shrinks the focused pane relative to the others.
+func (m *model) resizePane(delta int) {
+	if m.split == splitNone {
+		return
+	}
+
+	m.size = max(1, min(m.size+delta, 4*defaultPaneSize))
+	m.resize()
+}
+
+// shows reports whether a job is shown in any pane.
+func (m model) shows(t *tailedRun) bool {
+	return t == m.shown || m.showsElsewhere(t)
+}
+
+// showsElsewhere reports whether a job is shown in a pane other than the
+// focused one.
+func (m model) showsElsewhere(t *tailedRun) bool {
+	for idx, p := range m.panes {
+		if idx != m.focus && p.shown == t {
+			return true
+		}
+	}
+	return false
+}
+
+// cycle switches the focused pane to the next (or previous) job that isn't
+// already shown in another pane.
+func (m *model) cycle(delta int) {
+	idx := indexOf(m.tails, m.shown)
+	for range m.tails {
+		idx = (idx + delta + len(m.tails)) % len(m.tails)
+		if !m.showsElsewhere(m.tails[idx]) {
+			m.switchTo(idx)
+			return
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/aidansteele/ghal/workflows"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+type pickerItem struct {
+	title string
+	desc  string
+	value string
+}
+
+func (i pickerItem) Title() string       { return i.title }
+func (i pickerItem) Description() string { return i.desc }
+func (i pickerItem) FilterValue() string { return i.title }
+
+type pickerModel struct {
+	list   list.Model
+	chosen string
+}
+
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		if msg.String() == "enter" && m.list.FilterState() != list.Filtering {
+			if item, ok := m.list.SelectedItem().(pickerItem); ok {
+				m.chosen = item.value
+				return m, tea.Quit
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m pickerModel) View() string {
+	return m.list.View()
+}
+
+// interactive is true when we can show pickers and other prompts.
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// pick shows a fuzzy-filterable list and returns the value of the chosen item.
+func pick(title string, items []pickerItem) (string, error) {
+	if len(items) == 0 {
+		return "", errors.Errorf("nothing to choose from: %s", strings.ToLower(title))
+	}
+
+	listItems := make([]list.Item, len(items))
+	for idx, item := range items {
+		listItems[idx] = item
+	}
+
+	l := list.New(listItems, list.NewDefaultDelegate(), 0, 0)
+	l.Title = title
+
+	p := tea.NewProgram(pickerModel{list: l}, tea.WithAltScreen())
+	final, err := p.StartReturningModel()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	chosen := final.(pickerModel).chosen
+	if chosen == "" {
+		return "", errors.New("nothing chosen")
+	}
+
+	return chosen, nil
+}
+
+// pickWorkflow offers the workflows defined in .github/workflows, along with
+// any others the API knows about, annotated with their most recent run.
+func pickWorkflow(ctx context.Context, sess *session, filter runs.Filter) (string, error) {
+	owner, repo := sess.repo.Owner, sess.repo.Repo
+
+	local, broken, err := workflows.Local(".")
+	if err != nil {
+		return "", err
+	}
+
+	apiWfs, _, err := sess.api.Actions.ListWorkflows(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	recent, err := runs.RecentInRepo(ctx, sess.api.Actions, owner, repo, filter, 100)
+	if err != nil {
+		return "", err
+	}
+
+	latestByWorkflowId := map[int64]*github.WorkflowRun{}
+	for _, run := range recent {
+		if _, ok := latestByWorkflowId[run.GetWorkflowID()]; !ok {
+			latestByWorkflowId[run.GetWorkflowID()] = run
+		}
+	}
+
+	type candidate struct {
+		name   string
+		wfId   int64
+		local  bool
+		broken error
+	}
+
+	order := []string{}
+	candidates := map[string]*candidate{}
+	for _, wf := range local {
+		order = append(order, wf.FileName)
+		candidates[wf.FileName] = &candidate{name: wf.Name, local: true}
+	}
+
+	// broken files are still offered, as they may have been fixed on GitHub
+	for fileName, err := range broken {
+		order = append(order, fileName)
+		candidates[fileName] = &candidate{name: strings.TrimSuffix(fileName, path.Ext(fileName)), local: true, broken: err}
+	}
+	sort.Strings(order)
+
+	for _, wf := range apiWfs.Workflows {
+		// skips "dynamic" workflows like code scanning
+		dir, fileName := path.Split(wf.GetPath())
+		if dir != ".github/workflows/" {
+			continue
+		}
+
+		c, ok := candidates[fileName]
+		if !ok {
+			c = &candidate{name: wf.GetName()}
+			candidates[fileName] = c
+			order = append(order, fileName)
+		}
+		c.wfId = wf.GetID()
+	}
+
+	items := []pickerItem{}
+	for _, fileName := range order {
+		c := candidates[fileName]
+
+		desc := "no recent runs"
+		if run := latestByWorkflowId[c.wfId]; run != nil {
+			desc = fmt.Sprintf("#%d %s · %s · %s ago", *run.RunNumber, runStatus(run), run.GetHeadBranch(), age(run.GetCreatedAt().Time))
+		}
+
+		if !c.local {
+			desc += " · not in local checkout"
+		}
+
+		if c.broken != nil {
+			desc += fmt.Sprintf(" · couldn't read local file: %s", errors.Cause(c.broken))
+		}
+
+		items = append(items, pickerItem{
+			title: fmt.Sprintf("%s (%s)", fileName, c.name),
+			desc:  desc,
+			value: fileName,
+		})
+	}
+
+	return pick("Choose a workflow", items)
+}
+
+// pickJob offers the jobs seen in the workflow's recent runs (which include
+// expanded matrix and reusable-workflow job names) followed by any other jobs
+// defined in the workflow file.
+func pickJob(ctx context.Context, sess *session, ghl *ghlogs.Ghlogs, workflowFileName string, filter runs.Filter) (string, error) {
+	owner, repo := sess.repo.Owner, sess.repo.Repo
+
+	recent, err := runs.Recent(ctx, sess.api.Actions, owner, repo, workflowFileName, filter, 3)
+	if err != nil {
+		return "", err
+	}
+
+	seen := map[string]bool{}
+	items := []pickerItem{}
+	for _, run := range recent {
+		jobs, err := ghl.Jobs(ctx, ghlogs.Run{Owner: owner, Repo: repo, RunId: *run.ID})
+		if err != nil {
+			return "", err
+		}
+
+		for _, job := range jobs {
+			if seen[*job.Name] {
+				continue
+			}
+			seen[*job.Name] = true
+
+			status := job.GetConclusion()
+			if status == "" {
+				status = job.GetStatus()
+			}
+
+			items = append(items, pickerItem{
+				title: *job.Name,
+				desc:  fmt.Sprintf("%s in run #%d", status, *run.RunNumber),
+				value: *job.Name,
+			})
+		}
+	}
+
+	local, broken, err := workflows.Local(".")
+	if err != nil {
+		return "", err
+	}
+
+	if err := broken[workflowFileName]; err != nil && len(items) == 0 {
+		return "", errors.Errorf("no recent runs of %s to choose a job from, and its file couldn't be read: %s", workflowFileName, errors.Cause(err))
+	}
+
+	for _, wf := range local {
+		if wf.FileName != workflowFileName {
+			continue
+		}
+
+		for _, job := range wf.Jobs {
+			if seen[job.Name] {
+				continue
+			}
+			seen[job.Name] = true
+
+			items = append(items, pickerItem{
+				title: job.Name,
+				desc:  fmt.Sprintf("defined in %s as %s", wf.FileName, job.Id),
+				value: job.Name,
+			})
+		}
+	}
+
+	if len(items) == 1 {
+		return items[0].value, nil
+	}
+
+	return pick(fmt.Sprintf("Choose a job from %s", workflowFileName), items)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v43/github"
+	"io"
+	"strings"
+	"time"
+)
+
+// plainRenderer prints output as it streams in, for when there's no terminal
+// to run the TUI in (e.g. `ghal ... | tee log.txt`). Like `docker compose
+// logs`, each line is prefixed with where it came from:
+//
+//	deploy / Build | hello world
+type plainRenderer struct {
+	w          io.Writer
+	jobs       jobPattern
+	filter     lineFilter
+	concurrent bool
+
+	runs      map[int64]*github.WorkflowRun
+	steps     map[string]string // the last step printed for each job, by jobKey
+	jobsDone  map[string]bool
+	prefixLen int
+}
+
+var (
+	bannerStyle = lipgloss.NewStyle().Bold(true)
+	prefixStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#3B8EEA"))
+)
+
+func plainOutput(ctx context.Context, s streams, jobs jobPattern, filter lineFilter, w io.Writer, concurrent bool) error {
+	r := &plainRenderer{
+		w:          w,
+		jobs:       jobs,
+		filter:     filter,
+		concurrent: concurrent,
+		runs:       map[int64]*github.WorkflowRun{},
+		steps:      map[string]string{},
+		jobsDone:   map[string]bool{},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-s.errs:
+			return err
+		case run := <-s.tailed:
+			r.started(run)
+		case output := <-s.output:
+			r.print(output)
+		case update := <-s.updates:
+			r.update(update)
+		case transition := <-s.transitions:
+			if _, ok := r.runs[*transition.Run.ID]; ok && transition.To == "completed" {
+				r.banner("==> run #%d of %s completed: %s (%s)", transition.Run.GetRunNumber(), transition.Run.GetName(), transition.Conclusion, runDuration(transition.Run))
+			}
+		}
+	}
+}
+
+func (r *plainRenderer) banner(format string, args ...interface{}) {
+	fmt.Fprintln(r.w, bannerStyle.Render(fmt.Sprintf(format, args...)))
+}
+
+func (r *plainRenderer) started(run *github.WorkflowRun) {
+	r.runs[*run.ID] = run
+	forgetRun(r.steps, *run.ID)
+	forgetRun(r.jobsDone, *run.ID)
+	r.banner("==> run #%d of %s (attempt %d) started: %s", run.GetRunNumber(), run.GetName(), run.GetRunAttempt(), run.GetHTMLURL())
+}
+
+func (r *plainRenderer) update(update ghlogs.RunUpdate) {
+	id := update.Run.RunId
+	if _, ok := r.runs[id]; !ok {
+		return
+	}
+
+	for _, status := range update.Jobs {
+		job := status.Job
+		key := jobKey(id, job.GetName())
+		if r.jobs.matches(job.GetName()) && job.GetStatus() == "completed" && !r.jobsDone[key] {
+			r.jobsDone[key] = true
+			r.banner("==> %s completed: %s", job.GetName(), job.GetConclusion())
+		}
+	}
+}
+
+func (r *plainRenderer) print(output ghlogs.RunOutput) {
+	id := output.Run.RunId
+	if _, ok := r.runs[id]; !ok || !r.jobs.matches(output.JobName) {
+		return
+	}
+
+	stepName := output.StepName
+	if output.AssumedStepName {
+		stepName += "*"
+	}
+
+	key := jobKey(id, output.JobName)
+	if r.steps[key] != stepName {
+		r.steps[key] = stepName
+		r.banner("--> %s / step %d: %s", output.JobName, output.StepNumber, stepName)
+	}
+
+	prefix := fmt.Sprintf("%s / %s", output.JobName, stepName)
+	if r.concurrent {
+		prefix = fmt.Sprintf("#%d %s", r.runs[id].GetRunNumber(), prefix)
+	}
+
+	// like docker compose, the prefixes line up with the longest seen so far
+	if len(prefix) > r.prefixLen {
+		r.prefixLen = len(prefix)
+	}
+	prefix = prefixStyle.Render(prefix + strings.Repeat(" ", r.prefixLen-len(prefix)) + " |")
+
+	for _, raw := range output.Lines {
+		line := logfmt.Parse(raw)
+		if r.filter.hides(line) {
+			continue
+		}
+
+		text, ok := plainText(line)
+		if ok {
+			fmt.Fprintln(r.w, prefix, text)
+		}
+	}
+}
+
+// plainText is how a line reads without the TUI's styling, or false if it
+// shouldn't be printed at all.
+func plainText(line logfmt.Line) (string, bool) {
+	switch line.Kind {
+	case logfmt.KindEndGroup:
+		return "", false
+	case logfmt.KindGroup:
+		return "▾ " + line.Text, true
+	case logfmt.KindError:
+		return "Error: " + line.Text, true
+	case logfmt.KindWarning:
+		return "Warning: " + line.Text, true
+	case logfmt.KindNotice:
+		return "Notice: " + line.Text, true
+	default:
+		return line.Text, true
+	}
+}
+
+// runDuration is how long a run took, or has taken so far.
+func runDuration(run *github.WorkflowRun) time.Duration {
+	end := time.Now()
+	if run.GetStatus() == "completed" && !run.GetUpdatedAt().Time.IsZero() {
+		end = run.GetUpdatedAt().Time
+	}
+	return end.Sub(run.GetRunStartedAt().Time).Truncate(time.Second)
+}
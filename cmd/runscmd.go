@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type runsOptions struct {
+	*globalOptions
+	limit int
+}
+
+func newRunsCmd(g *globalOptions) *cobra.Command {
+	opts := &runsOptions{globalOptions: g}
+
+	cmd := &cobra.Command{
+		Use:     "runs <workflow file>",
+		Short:   "List recent runs of a workflow",
+		Example: "  ghal runs ci.yml --branch main -o json",
+		Args:    namedArgs(1, "workflow file"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.limit, "limit", "L", 10, "maximum number of runs to list")
+
+	return cmd
+}
+
+func (opts *runsOptions) run(ctx context.Context, workflowFileName string) error {
+	format, err := opts.outputFormat("text", "json")
+	if err != nil {
+		return err
+	}
+
+	if opts.limit < 1 || opts.limit > 100 {
+		return errors.Errorf("--limit must be between 1 and 100, got %d", opts.limit)
+	}
+
+	sess, err := opts.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	recent, err := runs.Recent(ctx, sess.api.Actions, sess.repo.Owner, sess.repo.Repo, workflowFileName, opts.filter(), opts.limit)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(recent))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNUMBER\tSTATUS\tBRANCH\tEVENT\tAGE\tTITLE")
+	for _, run := range recent {
+		fmt.Fprintf(w, "%d\t#%d\t%s\t%s\t%s\t%s\t%s\n",
+			*run.ID,
+			*run.RunNumber,
+			runStatus(run),
+			run.GetHeadBranch(),
+			run.GetEvent(),
+			age(run.GetCreatedAt().Time),
+			commitTitle(run),
+		)
+	}
+
+	return errors.WithStack(w.Flush())
+}
+
+// runStatus is the run's conclusion if it has one, otherwise its status.
+func runStatus(run *github.WorkflowRun) string {
+	if c := run.GetConclusion(); c != "" {
+		return c
+	}
+	return run.GetStatus()
+}
+
+func commitTitle(run *github.WorkflowRun) string {
+	msg := run.GetHeadCommit().GetMessage()
+	title, _, _ := strings.Cut(msg, "\n")
+	return title
+}
+
+func age(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// search is the state of a less-style regex search of the current buffer.
+// Matches are kept up to date as new lines stream in.
+type search struct {
+	input    textinput.Model
+	typing   bool
+	backward bool
+	re       *regexp.Regexp
+	err      error
+	matches  []searchMatch
+	current  int // index into matches, or -1 before the first n/N
+}
+
+type searchMatch struct {
+	line  int
+	start int
+	end   int
+}
+
+var (
+	searchMatchStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#5F5F00"))
+	searchCurrentStyle = lipgloss.NewStyle().Background(lipgloss.Color("#D7A000")).Foreground(lipgloss.Color("#000000"))
+)
+
+func newSearch() search {
+	input := textinput.New()
+	input.Prompt = "/"
+	return search{input: input, current: -1}
+}
+
+func (s search) active() bool {
+	return s.re != nil
+}
+
+// scan adds the matches in lines, the first of which is line number first.
+func (s *search) scan(t *tailedRun, first int) {
+	if s.re == nil {
+		return
+	}
+
+	t.eachLine(first, func(idx int, line logfmt.Line) bool {
+		for _, loc := range s.re.FindAllStringIndex(line.Text, -1) {
+			if loc[0] == loc[1] {
+				continue // don't highlight empty matches
+			}
+			s.matches = append(s.matches, searchMatch{line: idx, start: loc[0], end: loc[1]})
+		}
+		return true
+	})
+}
+
+// matchesLine reports whether there are any matches in the given line.
+func (s search) matchesLine(line int) bool {
+	idx := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line >= line })
+	return s.re != nil && idx < len(s.matches) && s.matches[idx].line == line
+}
+
+// highlightLine renders text (the given line) in the base style, with any
+// matches highlighted.
+func (s search) highlightLine(line int, text string, base lipgloss.Style) string {
+	if !s.matchesLine(line) {
+		return base.Render(text)
+	}
+
+	idx := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line >= line })
+	b := &strings.Builder{}
+	prev := 0
+	for ; idx < len(s.matches) && s.matches[idx].line == line; idx++ {
+		match := s.matches[idx]
+		style := searchMatchStyle
+		if idx == s.current {
+			style = searchCurrentStyle
+		}
+
+		b.WriteString(base.Render(text[prev:match.start]))
+		b.WriteString(style.Render(text[match.start:match.end]))
+		prev = match.end
+	}
+	b.WriteString(base.Render(text[prev:]))
+
+	return b.String()
+}
+
+// next selects the next match after (or, when backward, before) the given
+// line and returns its line number.
+func (s *search) next(fromLine int, backward bool) (int, bool) {
+	if len(s.matches) == 0 {
+		return 0, false
+	}
+
+	if s.current >= 0 {
+		if backward {
+			s.current = (s.current - 1 + len(s.matches)) % len(s.matches)
+		} else {
+			s.current = (s.current + 1) % len(s.matches)
+		}
+		return s.matches[s.current].line, true
+	}
+
+	if backward {
+		idx := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line > fromLine })
+		s.current = (idx - 1 + len(s.matches)) % len(s.matches)
+	} else {
+		idx := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line >= fromLine })
+		s.current = idx % len(s.matches)
+	}
+
+	return s.matches[s.current].line, true
+}
+
+func (s search) status() string {
+	switch {
+	case s.typing:
+		return s.input.View()
+	case s.err != nil:
+		return s.err.Error()
+	case s.re == nil:
+		return ""
+	case len(s.matches) == 0:
+		return fmt.Sprintf("%s%s: no matches", s.input.Prompt, s.re)
+	case s.current < 0:
+		return fmt.Sprintf("%s%s: %d matches", s.input.Prompt, s.re, len(s.matches))
+	default:
+		return fmt.Sprintf("%s%s: %d/%d", s.input.Prompt, s.re, s.current+1, len(s.matches))
+	}
+}
+
+// updateSearch handles keys while the search pattern is being typed.
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.search.typing = false
+		m.search.input.Blur()
+		return m, nil
+	case "enter":
+		m.search.typing = false
+		m.search.input.Blur()
+		m.search.re, m.search.err = nil, nil
+		m.search.matches = nil
+		m.search.current = -1
+
+		if pattern := m.search.input.Value(); pattern != "" {
+			m.search.re, m.search.err = regexp.Compile(pattern)
+		}
+
+		if t := m.tail(); t != nil && m.search.re != nil {
+			m.search.scan(t, 0)
+			m.searchNext(false)
+		}
+
+		m.refreshContent()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	return m, cmd
+}
+
+func (m *model) startSearch(backward bool) tea.Cmd {
+	m.search.typing = true
+	m.search.backward = backward
+	m.search.input.Prompt = "/"
+	if backward {
+		m.search.input.Prompt = "?"
+	}
+	m.search.input.SetValue("")
+	return m.search.input.Focus()
+}
+
+// searchNext moves to the next match in the search direction, or the
+// opposite direction when reverse is true (i.e. `N`). This stops following
+// new output so that the match stays in view.
+func (m *model) searchNext(reverse bool) {
+	backward := m.search.backward != reverse
+
+	line, ok := m.search.next(m.lineAt(m.viewport.YOffset), backward)
+	if !ok {
+		return
+	}
+
+	m.pause()
+	m.reveal(line)
+	m.refreshContent()
+
+	row := m.rowOf(line)
+	if row < m.viewport.YOffset || row >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(max(0, row-m.viewport.Height/2))
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v43/github"
+	"time"
+)
+
+const sidebarWidth = 40
+
+var (
+	sidebarStyle = lipgloss.NewStyle().
+			Width(sidebarWidth - 1).
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderRight(true)
+	stepDimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+	stepSuccessStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#067D17"))
+	stepFailureStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#CC0000"))
+	stepActiveStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#D7A000"))
+)
+
+func (m model) steps() []*github.TaskStep {
+	t := m.tail()
+	if t == nil || t.job == nil {
+		return nil
+	}
+	return t.job.Steps
+}
+
+// currentStepIndex is the index of the step at the top of the viewport.
+func (m model) currentStepIndex() int {
+	t := m.tail()
+	if t == nil {
+		return 0
+	}
+
+	number := 0
+	top := m.lineAt(m.viewport.YOffset)
+	for _, name := range t.stepNames {
+		if name.offset <= top {
+			number = name.stepNumber
+		}
+	}
+
+	for idx, step := range m.steps() {
+		if int(step.GetNumber()) == number {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+// updateSidebar handles keys while the sidebar, which lists the steps of the
+// tailed job, has the focus. Choosing a step scrolls to its output.
+func (m model) updateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	steps := m.steps()
+	cmd := m.updatePanel(&m.sidebar, msg, "s", len(steps), func(cursor int) tea.Cmd {
+		if offset, ok := m.tail().stepOffset(int(steps[cursor].GetNumber())); ok {
+			m.scrollTo(m.rowOf(offset))
+		}
+		return nil
+	})
+	return m, cmd
+}
+
+func (m model) sidebarView() string {
+	steps := m.steps()
+
+	lines := []string{}
+	for _, step := range steps {
+		icon, style := stepIcon(step)
+		name := truncate(step.GetName(), sidebarWidth-12)
+		lines = append(lines, fmt.Sprintf("%s %-*s %7s", style.Render(icon), sidebarWidth-12, name, stepDuration(step)))
+	}
+
+	height := m.viewport.Height
+	view := m.sidebar.view(lines, stepDimStyle.Render("waiting for steps"), height)
+	return sidebarStyle.Height(height).MaxHeight(height).Render(view)
+}
+
+func stepIcon(step *github.TaskStep) (string, lipgloss.Style) {
+	return statusIcon(step.GetStatus(), step.GetConclusion())
+}
+
+// statusIcon is how the status of a step, job or run is shown in lists.
+func statusIcon(status, conclusion string) (string, lipgloss.Style) {
+	switch status {
+	case "completed":
+		switch conclusion {
+		case "success":
+			return "✓", stepSuccessStyle
+		case "skipped":
+			return "-", stepDimStyle
+		case "cancelled":
+			return "⊘", stepDimStyle
+		default:
+			return "✗", stepFailureStyle
+		}
+	case "in_progress":
+		return "●", stepActiveStyle
+	default:
+		return "○", stepDimStyle
+	}
+}
+
+// stepDuration is the time a step has taken so far, or in total once it has
+// completed.
+func stepDuration(step *github.TaskStep) string {
+	started := step.GetStartedAt().Time
+	if started.IsZero() {
+		return ""
+	}
+
+	end := time.Now()
+	if completed := step.GetCompletedAt().Time; !completed.IsZero() {
+		end = completed
+	}
+
+	return end.Sub(started).Truncate(time.Second).String()
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
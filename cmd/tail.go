@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+type tailOptions struct {
+	*globalOptions
+	concurrent    bool
+	plain         bool
+	gap           time.Duration
+	exportFormat  string
+	memoryCap     int
+	notify        []string
+	notifyOn      []string
+	notifyCmd     string
+	webhookAddr   string
+	webhookSecret string
+	grep          string
+	grepV         string
+}
+
+func newTailCmd(g *globalOptions) *cobra.Command {
+	opts := &tailOptions{globalOptions: g}
+
+	cmd := &cobra.Command{
+		Use:   "tail [workflow file] [job name]",
+		Short: "Follow the live logs of a job, attaching to new runs as they start",
+		Long: `Follow the live logs of a job, attaching to new runs as they start.
+
+When the workflow file or job name is omitted, they can be chosen from a list.
+
+The job name can also be a glob, or a regex between slashes, and matches the
+base name of matrix jobs too. Every job that matches is followed.`,
+		Example: `  # tail the "deploy" job of .github/workflows/e2e.yml
+  ghal tail e2e.yml deploy
+
+  # choose a job from e2e.yml
+  ghal tail e2e.yml
+
+  # every leg of the "build" matrix, and every job of a called workflow
+  ghal tail ci.yml build
+  ghal tail ci.yml 'deploy / *'
+  ghal tail ci.yml '/^(unit|integration) tests/'`,
+		Args: namedArgs(0, "workflow file", "job name"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context(), args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.concurrent, "concurrent", os.Getenv("GHAL_CONCURRENT") != "", "keep tailing every active run instead of replacing them")
+	cmd.Flags().BoolVar(&opts.plain, "plain", false, "print output as it streams in instead of starting the TUI (the default when not in a terminal)")
+	cmd.Flags().DurationVar(&opts.gap, "gap", 30*time.Second, "highlight lines logged this long after the previous line (0 to disable)")
+	cmd.Flags().StringVar(&opts.exportFormat, "export-format", "plain", "format of saved and copied logs: plain or raw")
+	cmd.Flags().IntVar(&opts.memoryCap, "memory-cap", 64, "MiB of each job's output to keep in memory before spilling the rest to a temporary file")
+	cmd.Flags().StringSliceVar(&opts.notify, "notify", nil, "how to notify when the job concludes: bell, osc9, osc777 (desktop notifications) or title")
+	cmd.Flags().StringSliceVar(&opts.notifyOn, "notify-on", []string{"job"}, "what to notify about: job (concludes), run (concludes) or error (first ##[error] line)")
+	cmd.Flags().StringVar(&opts.notifyCmd, "notify-cmd", "", "shell command to run for notifications, with $GHAL_CONCLUSION, $GHAL_URL, $GHAL_EVENT, $GHAL_TITLE and $GHAL_MESSAGE set")
+	cmd.Flags().StringVar(&opts.webhookAddr, "webhook-addr", os.Getenv("GHAL_WEBHOOK_ADDR"), "receive webhooks on this address instead of polling for runs")
+	cmd.Flags().StringVar(&opts.webhookSecret, "webhook-secret", "", "shared secret used to verify webhook signatures (default: $GHAL_WEBHOOK_SECRET)")
+	cmd.Flags().StringVar(&opts.grep, "grep", "", "only show lines matching this regex (group markers are always shown)")
+	cmd.Flags().StringVar(&opts.grepV, "grep-v", "", "hide lines matching this regex")
+
+	return cmd
+}
+
+func (opts *tailOptions) run(ctx context.Context, args []string) error {
+	if opts.output == "" {
+		opts.output = opts.config.Output
+	}
+
+	format, err := opts.outputFormat("tui", "plain", "jsonl")
+	if err != nil {
+		return err
+	}
+
+	err = applyColors(opts.config.Colors)
+	if err != nil {
+		return err
+	}
+
+	keys, err := newKeymap(opts.config.Keys)
+	if err != nil {
+		return err
+	}
+
+	filter, err := newLineFilter(opts.grep, opts.grepV)
+	if err != nil {
+		return err
+	}
+
+	if !contains(exportFormats, opts.exportFormat) {
+		return errors.Errorf("unsupported export format %q, expected one of: %s", opts.exportFormat, strings.Join(exportFormats, ", "))
+	}
+
+	n, err := newNotifier(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.webhookSecret == "" {
+		opts.webhookSecret = os.Getenv("GHAL_WEBHOOK_SECRET")
+	}
+
+	if opts.webhookAddr != "" && opts.webhookSecret == "" {
+		return errors.New("--webhook-secret (or GHAL_WEBHOOK_SECRET) must be set when receiving webhooks")
+	}
+
+	sess, err := opts.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	ghl := ghlogs.New(
+		sess.api,
+		sess.client,
+		os.Getenv("GITHUB_USER_SESSION"),
+	)
+
+	workflowFileName, jobName, err := opts.pickMissing(ctx, sess, ghl, opts.resolveArgs(args))
+	if err != nil {
+		return err
+	}
+
+	jobs, err := parseJobPattern(jobName)
+	if err != nil {
+		return err
+	}
+
+	allRunsCh := make(chan *github.WorkflowRun)
+	s := newStreams()
+
+	owner, repo := sess.repo.Owner, sess.repo.Repo
+	if opts.webhookAddr != "" {
+		l, err := net.Listen("tcp", opts.webhookAddr)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		wh := runs.NewWebhook(sess.api.Actions, allRunsCh, s.transitions, []byte(opts.webhookSecret), owner, repo, workflowFileName, opts.filter())
+		go receiveWebhooks(ctx, wh, l, s)
+		s.reruns = nil // new attempts are delivered as webhooks too
+	} else {
+		go pollRuns(ctx, sess, allRunsCh, s, workflowFileName, opts.filter())
+	}
+
+	plain := format == "plain" || opts.plain || !interactive()
+	if format != "jsonl" && !plain {
+		n.terminal = s.terminal
+	}
+
+	go monitorRuns(ctx, ghl, allRunsCh, s, opts.concurrent)
+	s = n.wrap(ctx, s, jobs)
+
+	if format == "jsonl" {
+		return jsonlOutput(ctx, s, jobs, filter, os.Stdout)
+	}
+
+	if plain {
+		return plainOutput(ctx, s, jobs, filter, os.Stdout, opts.concurrent)
+	}
+
+	h := newHistory(sess.api.Actions, owner, repo, workflowFileName, opts.filter())
+	return tailOutput(ctx, ghl, s, jobs, h, filter, keys, opts)
+}
+
+// resolveArgs expands an alias from the config file (e.g. `ghal prod`) into
+// its workflow file and job name, and fills in missing arguments from the
+// config file's defaults.
+func (opts *tailOptions) resolveArgs(args []string) []string {
+	cfg := opts.config
+	if len(args) == 1 {
+		if alias, ok := cfg.Aliases[args[0]]; ok {
+			if opts.branch == "" {
+				opts.branch = alias.Branch
+			}
+			return []string{alias.Workflow, alias.Job}
+		}
+	}
+
+	if len(args) == 0 && cfg.Workflow != "" {
+		args = []string{cfg.Workflow}
+	}
+
+	if len(args) == 1 && args[0] == cfg.Workflow && cfg.Job != "" {
+		args = append(args, cfg.Job)
+	}
+
+	return args
+}
+
+// pickMissing returns the workflow file and job name from args, and prompts
+// for any that are missing.
+func (opts *tailOptions) pickMissing(ctx context.Context, sess *session, ghl *ghlogs.Ghlogs, args []string) (string, string, error) {
+	names := []string{"workflow file", "job name"}
+	args = append(args, make([]string, len(names)-len(args))...)
+	if args[1] != "" {
+		return args[0], args[1], nil
+	}
+
+	if !interactive() {
+		missing := names[1]
+		if args[0] == "" {
+			missing = names[0]
+		}
+		return "", "", errors.Errorf("missing argument: %s (it can only be chosen interactively in a terminal)", missing)
+	}
+
+	var err error
+	if args[0] == "" {
+		args[0], err = pickWorkflow(ctx, sess, opts.filter())
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	args[1], err = pickJob(ctx, sess, ghl, args[0], opts.filter())
+	return args[0], args[1], err
+}
+
+// receiveWebhooks serves the webhook on l until ctx is done or serving fails.
+func receiveWebhooks(ctx context.Context, wh *runs.Webhook, l net.Listener, s streams) {
+	err := wh.Serve(ctx, l)
+	if err != nil {
+		s.fail(ctx, errors.WithMessage(err, "stopped receiving webhooks"))
+	}
+}
+
+// pollRuns sends runs of the workflow to ch, and their transitions to s, until
+// ctx is done or polling fails.
+func pollRuns(ctx context.Context, sess *session, ch chan *github.WorkflowRun, s streams, workflowFileName string, filter runs.Filter) {
+	err := runs.Monitor(ctx, sess.api.Actions, ch, s.transitions, s.reruns, sess.repo.Owner, sess.repo.Repo, workflowFileName, filter)
+	if err != nil {
+		s.fail(ctx, errors.WithMessage(err, "stopped polling for runs"))
+	}
+}
+
+// monitorRuns tails each run sent on runch. By default, a new run cancels the
+// tail of the previous one. In concurrent mode, every run is tailed until it
+// completes.
+func monitorRuns(ctx context.Context, ghl *ghlogs.Ghlogs, runch chan *github.WorkflowRun, s streams, concurrent bool) {
+	ctx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+
+	prevCancel := func() {}
+	for run := range runch {
+		newCtx := ctx
+		if !concurrent {
+			prevCancel()
+			var cancel context.CancelFunc
+			newCtx, cancel = context.WithCancel(ctx)
+			prevCancel = cancel
+		}
+
+		go func(ctx context.Context, run *github.WorkflowRun) {
+			s.tailed <- run
+			err := ghl.Logs(ctx, s.output, s.updates, runOf(run))
+
+			ctxerr := ctx.Err()
+			cause := errors.Cause(err)
+			if err != nil && cause != ctxerr {
+				s.fail(ctx, errors.WithMessagef(err, "couldn't follow run #%d", run.GetRunNumber()))
+			}
+		}(newCtx, run)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"time"
+)
+
+// timestampMode is how the timestamp of each line is shown, cycled with `t`.
+type timestampMode int
+
+const (
+	timestampsHidden timestampMode = iota
+	timestampsWallClock
+	timestampsSinceStep
+)
+
+func (mode timestampMode) next() timestampMode {
+	return (mode + 1) % 3
+}
+
+var gapStyle = lipgloss.NewStyle().Background(lipgloss.Color("#D7A000")).Foreground(lipgloss.Color("#000000"))
+
+// stepStart returns when the step that a line belongs to started, according to
+// the API if possible, otherwise when its first line was logged.
+func (t *tailedRun) stepStart(idx int) time.Time {
+	step := stepNameOffset{offset: -1}
+	for _, name := range t.stepNames {
+		if name.offset <= idx {
+			step = name
+		}
+	}
+
+	if t.job != nil && step.stepNumber != 0 {
+		for _, s := range t.job.Steps {
+			if int(s.GetNumber()) == step.stepNumber && !s.GetStartedAt().Time.IsZero() {
+				return s.GetStartedAt().Time
+			}
+		}
+	}
+
+	if step.offset < 0 || step.offset >= len(t.lines) {
+		return time.Time{}
+	}
+
+	return t.lines[step.offset].at
+}
+
+// timestampPrefix renders the timestamp column of a line. Lines that were
+// logged long after the previous one are highlighted to show where a step
+// hung, even when timestamps are hidden.
+func (m model) timestampPrefix(t *tailedRun, idx int) string {
+	line := t.lines[idx]
+	gap := m.gapThreshold > 0 && line.gap >= m.gapThreshold
+
+	text := ""
+	switch m.timestamps {
+	case timestampsHidden:
+		if !gap {
+			return ""
+		}
+		return gapStyle.Render(fmt.Sprintf("⋯ +%s", line.gap.Truncate(time.Second))) + " "
+	case timestampsWallClock:
+		text = line.at.Local().Format("15:04:05")
+	case timestampsSinceStep:
+		text = formatSince(line.at.Sub(t.stepStart(idx)))
+	}
+
+	if gap {
+		return gapStyle.Render(text) + " "
+	}
+	return dimStyle.Render(text) + " "
+}
+
+// formatSince formats d as +MM:SS, or +H:MM:SS for durations over an hour.
+func formatSince(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	secs := int(d.Seconds())
+	if secs >= 3600 {
+		return fmt.Sprintf("+%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("+%02d:%02d", secs/60, secs%60)
+}
@@ -0,0 +1,941 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/logfmt"
+	"github.com/aidansteele/ghal/logstore"
+	"github.com/aidansteele/ghal/runs"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type stepNameOffset struct {
+	stepName   string
+	stepNumber int
+	offset     int
+}
+
+type tailedRun struct {
+	wfRun     *github.WorkflowRun
+	jobName   string // empty until a matching job shows up
+	job       *github.WorkflowJob
+	archived  bool // loaded from the run history, rather than tailed live
+	stepNames []stepNameOffset
+	store     *logstore.Store // the raw text of each line
+	lines     []logLine
+	groups    []*logGroup
+	dirty     int // the first line whose visibility may have changed since it was laid out
+
+	annotations      []*github.CheckRunAnnotation
+	annotationsCount int    // as of the last time they were fetched
+	actor            string // who triggered the run
+	filtered         int    // lines hidden by the filter
+
+	openGroup     int
+	selectedGroup int
+	expandAll     bool
+}
+
+func newTailedRun(wfRun *github.WorkflowRun, memoryCap int) *tailedRun {
+	return &tailedRun{
+		wfRun:         wfRun,
+		store:         logstore.New(memoryCap),
+		stepNames:     []stepNameOffset{{stepName: "", offset: -1}},
+		openGroup:     -1,
+		selectedGroup: -1,
+	}
+}
+
+func (t *tailedRun) completed() bool {
+	return *t.wfRun.Status == "completed"
+}
+
+// parsed reads a line back from the store.
+func (t *tailedRun) parsed(idx int) logfmt.Line {
+	raw, err := t.store.Line(idx)
+	if err != nil {
+		return logfmt.Line{Text: fmt.Sprintf("(couldn't read line: %s)", err)}
+	}
+	return logfmt.Parse(raw)
+}
+
+// readChunk is how many lines are read back from the store at once when going
+// through all of them, so that --memory-cap holds for long logs too.
+const readChunk = 1000
+
+// eachLine calls fn with every line from the given one onwards, reading them
+// back from the store a chunk at a time. It stops early if fn returns false.
+func (t *tailedRun) eachLine(from int, fn func(idx int, line logfmt.Line) bool) error {
+	for from < t.store.Len() {
+		to := min(from+readChunk, t.store.Len())
+		raws, err := t.store.Lines(from, to)
+		if err != nil {
+			return err
+		}
+
+		for idx, raw := range raws {
+			if !fn(from+idx, logfmt.Parse(raw)) {
+				return nil
+			}
+		}
+
+		from = to
+	}
+
+	return nil
+}
+
+// invalidate marks the lines from the given one onwards as needing to be
+// laid out again.
+func (t *tailedRun) invalidate(from int) {
+	if from < t.dirty {
+		t.dirty = from
+	}
+}
+
+// stepOffset returns the first line of output for the step, if there is any.
+func (t *tailedRun) stepOffset(stepNumber int) (int, bool) {
+	for _, name := range t.stepNames {
+		if name.stepNumber == stepNumber && name.offset >= 0 {
+			return name.offset, true
+		}
+	}
+	return 0, false
+}
+
+// streams are the channels that a renderer consumes while tailing.
+type streams struct {
+	tailed      chan *github.WorkflowRun
+	output      chan ghlogs.RunOutput
+	updates     chan ghlogs.RunUpdate
+	transitions chan runs.Transition
+	reruns      chan runs.Rerun // runs to follow again once they have been re-run, if polling
+	terminal    chan string     // escape sequences for the terminal emulator, if the TUI is writing them
+	errs        chan error      // failures of the polling, webhooks or log streaming
+}
+
+func newStreams() streams {
+	return streams{
+		tailed:      make(chan *github.WorkflowRun),
+		output:      make(chan ghlogs.RunOutput),
+		updates:     make(chan ghlogs.RunUpdate),
+		transitions: make(chan runs.Transition),
+		reruns:      make(chan runs.Rerun),
+		terminal:    make(chan string),
+		errs:        make(chan error),
+	}
+}
+
+// fail hands err to the renderer, which reports it without tearing down the
+// terminal.
+func (s streams) fail(ctx context.Context, err error) {
+	select {
+	case s.errs <- err:
+	case <-ctx.Done():
+	}
+}
+
+// failedMsg is an error from the background, shown as a notice.
+type failedMsg struct {
+	err error
+}
+
+type model struct {
+	ctx        context.Context
+	ghl        *ghlogs.Ghlogs
+	jobs       jobPattern
+	concurrent bool
+	tails      []*tailedRun // grouped by run, with an entry for each matching job
+
+	streams   streams
+	ready     bool
+	width     int
+	height    int
+	memoryCap int
+
+	pane            // the one with focus
+	split    layout // how panes are arranged, if there's more than one
+	panes    []pane
+	focus    int
+	inactive bool // when rendering a pane without focus
+
+	timestamps   timestampMode
+	gapThreshold time.Duration
+	exportFormat string
+	notice       string // the result of the last action, until the next key
+	confirm      *confirmation
+
+	keys        keymap
+	sidebar     panel // the steps of the tailed job
+	search      search
+	annotations panel // the check run annotations of the tailed job
+	history     history
+	filter      lineFilter
+	filterBar   filterBar
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(
+		m.waitForActivity(),
+		tick(time.Second),
+	)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.search.typing {
+			return m.updateSearch(msg)
+		}
+
+		if m.filterBar.typing {
+			return m.updateFilter(msg)
+		}
+
+		if m.sidebar.visible {
+			return m.updateSidebar(msg)
+		}
+
+		if m.annotations.visible {
+			return m.updateAnnotations(msg)
+		}
+
+		if m.history.visible {
+			return m.updateHistory(msg)
+		}
+
+		m.notice = ""
+		if m.confirm != nil {
+			return m.updateConfirm(msg)
+		}
+
+		switch m.keys.translate(msg.String()) {
+		// These keys should exit the program.
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "tab":
+			m.cycle(1)
+		case "shift+tab":
+			m.cycle(-1)
+		case "x":
+			m.dismiss()
+		case "s":
+			m.sidebar.open(m.currentStepIndex())
+			m.resize()
+		case "a":
+			m.annotations.open(0)
+			m.resize()
+		case "f":
+			return m, m.startFilter()
+		case "/":
+			return m, m.startSearch(false)
+		case "?":
+			return m, m.startSearch(true)
+		case "n":
+			m.searchNext(false)
+		case "N":
+			m.searchNext(true)
+		case "]":
+			m.selectGroup(1)
+		case "[":
+			m.selectGroup(-1)
+		case "enter":
+			m.toggleGroup()
+		case "e":
+			m.toggleAllGroups()
+		case "G", "end":
+			m.follow()
+		case "g", "home":
+			m.viewport.GotoTop()
+			m.pause()
+		case "t":
+			m.timestamps = m.timestamps.next()
+			m.refreshContent()
+		case "w":
+			m.save(false)
+		case "W":
+			m.save(true)
+		case "y":
+			return m, m.copy(false)
+		case "Y":
+			return m, m.copy(true)
+		case "C":
+			m.cancelRun()
+		case "R":
+			m.rerun(false)
+		case "F":
+			m.rerun(true)
+		case "o":
+			return m, m.open(false)
+		case "O":
+			return m, m.open(true)
+		case "H":
+			m.history.open(0)
+			m.resize()
+			return m, m.fetchHistory()
+		case "L":
+			m.goLive()
+		case "|":
+			m.splitPane(splitVertical)
+		case "-":
+			m.splitPane(splitHorizontal)
+		case "p":
+			m.focusPane(m.focus + 1)
+		case "c":
+			m.closePane()
+		case ">":
+			m.resizePane(1)
+		case "<":
+			m.resizePane(-1)
+		}
+	case actionResultMsg:
+		m.notice = msg.notice
+	case terminalMsg:
+		io.WriteString(os.Stdout, string(msg))
+	case notificationMsg:
+		io.WriteString(os.Stdout, string(msg))
+		cmds = append(cmds, m.waitForActivity())
+	case failedMsg:
+		m.notice = msg.err.Error()
+		cmds = append(cmds, m.waitForActivity())
+	case annotationsMsg:
+		m.setAnnotations(msg)
+	case historyMsg:
+		m.setHistory(msg)
+	case historyLogsMsg:
+		cmds = append(cmds, m.addArchived(msg))
+	case tickMsg:
+		return m, tick(time.Second)
+	case *github.WorkflowRun:
+		m.add(msg)
+		for _, t := range m.tails {
+			if *t.wfRun.ID == *msg.ID {
+				cmds = append(cmds, m.fetchActor(t))
+			}
+		}
+		cmds = append(cmds, m.waitForActivity())
+	case actorMsg:
+		for _, t := range m.tails {
+			if *t.wfRun.ID == msg.runId {
+				t.actor = msg.actor
+			}
+		}
+	case runs.Transition:
+		for _, t := range m.tails {
+			if *t.wfRun.ID == *msg.Run.ID {
+				t.wfRun = msg.Run
+			}
+		}
+		cmds = append(cmds, m.waitForActivity())
+	case ghlogs.RunUpdate:
+		for _, t := range m.tails {
+			if *t.wfRun.ID != msg.Run.RunId {
+				continue
+			}
+
+			t.wfRun = msg.WorkflowRun
+		}
+
+		for _, status := range msg.Jobs {
+			if !m.jobs.matches(status.Job.GetName()) {
+				continue
+			}
+
+			if t := m.tailFor(msg.Run.RunId, status.Job.GetName()); t != nil {
+				t.job = status.Job
+				cmds = append(cmds, m.fetchAnnotations(t, status.CheckRun))
+			}
+		}
+		cmds = append(cmds, m.waitForActivity())
+	case ghlogs.RunOutput:
+		if t := m.append(msg); t != nil {
+			if t == m.tail() {
+				first := len(t.lines) - len(msg.Lines)
+				m.search.scan(t, first)
+			}
+
+			m.eachPane(func(p *model) {
+				if p.tail() != t {
+					return
+				}
+
+				rows := len(p.rows)
+				p.refreshContent()
+
+				if p.following {
+					p.viewport.GotoBottom()
+				} else {
+					p.unseen = max(0, p.unseen+len(p.rows)-rows)
+				}
+			})
+		}
+		cmds = append(cmds, m.waitForActivity())
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		if !m.ready {
+			// Since this program is using the full size of the viewport we
+			// need to wait until we've received the window dimensions before
+			// we can initialize the viewport. The initial dimensions come in
+			// quickly, though asynchronously, which is why we wait for them
+			// here.
+			m.ready = true
+			m.refreshContent()
+		}
+
+		m.resize()
+	}
+
+	if m.search.typing {
+		m.search.input, cmd = m.search.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	if m.filterBar.typing {
+		m.filterBar.input, cmd = m.filterBar.input.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		m.viewport.scroll(m.keys.translate(msg.String()))
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	if _, ok := msg.(tea.KeyMsg); ok && !m.viewport.AtBottom() {
+		m.pause()
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// tail returns the run currently shown in the viewport, if any.
+func (m model) tail() *tailedRun {
+	return m.shown
+}
+
+// resize fits each pane's viewport into the space left over by its header,
+// footer and sidebar.
+func (m *model) resize() {
+	if !m.ready {
+		return
+	}
+
+	m.eachPane(func(p *model) {
+		p.fit()
+	})
+}
+
+func (m *model) fit() {
+	headerHeight := lipgloss.Height(m.headerView())
+	footerHeight := lipgloss.Height(m.footerView())
+
+	m.viewport.Width = m.width
+	m.viewport.Height = max(0, m.height-headerHeight-footerHeight-m.annotationsViewHeight()-m.historyViewHeight())
+	if m.sidebar.visible {
+		m.viewport.Width = max(0, m.width-sidebarWidth)
+	}
+	m.viewport.SetRows(len(m.rows))
+	if m.following {
+		m.viewport.GotoBottom()
+	}
+}
+
+// add starts buffering a newly tailed run. Without concurrent mode the new run
+// replaces the old one, otherwise it's added as another entry - and shown
+// straight away only if the current entry has already finished. A new attempt
+// of a run replaces the buffers of the previous attempt's jobs.
+func (m *model) add(wfRun *github.WorkflowRun) {
+	t := newTailedRun(wfRun, m.memoryCap)
+
+	cur, replaced := m.tail(), -1
+	kept := []*tailedRun{}
+	for _, existing := range m.tails {
+		if *existing.wfRun.ID != *wfRun.ID {
+			kept = append(kept, existing)
+			continue
+		}
+
+		existing.store.Close()
+		if replaced < 0 {
+			replaced = len(kept)
+			kept = append(kept, t)
+		}
+	}
+
+	if replaced >= 0 {
+		m.tails = kept
+		if indexOf(kept, cur) < 0 {
+			m.switchTo(replaced)
+		}
+		m.closeStalePanes()
+		return
+	}
+
+	// runs loaded from the history stay until they're dismissed, and the new
+	// run doesn't take over from one that's being looked at
+	if !m.concurrent {
+		kept := []*tailedRun{}
+		for _, existing := range m.tails {
+			if existing.archived {
+				kept = append(kept, existing)
+			} else {
+				existing.store.Close()
+			}
+		}
+
+		m.tails = append(kept, t)
+		m.closeStalePanes()
+		if cur == nil || !cur.archived || indexOf(m.tails, cur) < 0 {
+			m.switchTo(len(m.tails) - 1)
+		}
+		return
+	}
+
+	m.tails = append(m.tails, t)
+	if cur == nil || cur == t || cur.completed() && !cur.archived {
+		m.switchTo(len(m.tails) - 1)
+	}
+}
+
+// dismiss drops the buffer of the current run, as long as it has finished.
+func (m *model) dismiss() {
+	t := m.tail()
+	if t == nil || !t.completed() {
+		return
+	}
+
+	idx := indexOf(m.tails, t)
+	t.store.Close()
+	m.tails = append(m.tails[:idx], m.tails[idx+1:]...)
+	m.switchTo(idx)
+
+	// a job is only ever shown in one pane
+	if m.showsElsewhere(m.shown) {
+		m.cycle(1)
+	}
+	if m.showsElsewhere(m.shown) {
+		m.closePane()
+	}
+}
+
+func (m *model) switchTo(idx int) {
+	m.search.matches = nil
+	m.search.current = -1
+
+	if len(m.tails) == 0 {
+		m.shown = nil
+		m.resize()
+		m.refreshContent()
+		return
+	}
+
+	m.shown = m.tails[(idx+len(m.tails))%len(m.tails)]
+	m.resize() // the header is taller once there's a run to describe
+	m.search.scan(m.tail(), 0)
+	m.refreshContent()
+	m.follow()
+}
+
+// refreshContent lays out the current run's visible lines into rows. Only the
+// lines after the first one that has changed are laid out again; lines are
+// only rendered once they are scrolled into view.
+func (m *model) refreshContent() {
+	t := m.tail()
+	if t == nil {
+		m.rows = m.rows[:0]
+		m.laidOut = nil
+		m.viewport.SetRows(0)
+		return
+	}
+
+	from := t.dirty
+	if m.laidOut != t {
+		from = 0
+		m.laidOut = t
+	}
+
+	m.rows = m.rows[:sort.SearchInts(m.rows, from)]
+	for idx := from; idx < len(t.lines); idx++ {
+		if t.visible(idx) {
+			m.rows = append(m.rows, idx)
+		}
+	}
+
+	t.dirty = len(t.lines)
+	m.viewport.SetRows(len(m.rows))
+}
+
+// lineAt returns the line shown in the given row of the viewport.
+func (m model) lineAt(row int) int {
+	if row < 0 || len(m.rows) == 0 {
+		return 0
+	}
+	if row >= len(m.rows) {
+		return m.rows[len(m.rows)-1]
+	}
+	return m.rows[row]
+}
+
+// rowOf returns the row of the viewport that shows the given line, or the
+// next visible line after it.
+func (m model) rowOf(line int) int {
+	return sort.SearchInts(m.rows, line)
+}
+
+// tailFor returns the entry for a job of a tailed run. The first matching job
+// takes over the entry that was waiting for it, and each one after that gets
+// an entry of its own, next to the others from the same run.
+func (m *model) tailFor(runId int64, jobName string) *tailedRun {
+	last := -1
+	for idx, t := range m.tails {
+		if *t.wfRun.ID != runId {
+			continue
+		}
+
+		if t.jobName == jobName {
+			return t
+		}
+		last = idx
+	}
+
+	if last < 0 {
+		return nil
+	}
+
+	if waiting := m.tails[last]; waiting.jobName == "" {
+		waiting.jobName = jobName
+		return waiting
+	}
+
+	t := newTailedRun(m.tails[last].wfRun, m.memoryCap)
+	t.jobName = jobName
+	t.actor = m.tails[last].actor
+
+	m.tails = append(m.tails[:last+1], append([]*tailedRun{t}, m.tails[last+1:]...)...)
+	m.resize() // the tabs may have just appeared
+
+	return t
+}
+
+// jobLabel is the name of an entry's job, or what it's waiting for.
+func (m model) jobLabel(t *tailedRun) string {
+	if t == nil || t.jobName == "" {
+		return m.jobs.String()
+	}
+	return t.jobName
+}
+
+func indexOf(tails []*tailedRun, t *tailedRun) int {
+	for idx, candidate := range tails {
+		if candidate == t {
+			return idx
+		}
+	}
+	return -1
+}
+
+// append buffers output for the job it belongs to, and returns its entry if
+// it's being tailed.
+func (m *model) append(output ghlogs.RunOutput) *tailedRun {
+	if !m.jobs.matches(output.JobName) {
+		return nil
+	}
+
+	t := m.tailFor(output.Run.RunId, output.JobName)
+	if t == nil {
+		return nil
+	}
+
+	m.buffer(t, output)
+	return t
+}
+
+// buffer adds output to a job's buffer.
+func (m *model) buffer(t *tailedRun, output ghlogs.RunOutput) {
+	err := t.buffer(output, m.filter.hides)
+	if err != nil {
+		m.notice = fmt.Sprintf("couldn't buffer output: %s", errors.Cause(err))
+	}
+}
+
+// buffer adds output to the buffer, noting where each step starts. The lines
+// that hides returns true for are hidden by the filter.
+func (t *tailedRun) buffer(output ghlogs.RunOutput, hides func(line logfmt.Line) bool) error {
+	stepName := output.StepName
+	if output.AssumedStepName {
+		stepName += "*"
+	}
+
+	latestStep := t.stepNames[len(t.stepNames)-1].stepName
+	if stepName != latestStep {
+		t.stepNames = append(t.stepNames, stepNameOffset{
+			stepName:   stepName,
+			stepNumber: output.StepNumber,
+			offset:     len(t.lines),
+		})
+	}
+
+	err := t.store.Append(output.Lines...)
+
+	received := time.Now()
+	for _, raw := range output.Lines {
+		line := logfmt.Parse(raw)
+		t.appendLine(line, received)
+
+		if hides(line) {
+			t.lines[len(t.lines)-1].filtered = true
+			t.filtered++
+		}
+	}
+
+	return err
+}
+
+var titleStyle = func() lipgloss.Style {
+	b := lipgloss.RoundedBorder()
+	b.Right = "├"
+	return lipgloss.NewStyle().
+		BorderStyle(b).
+		Padding(0, 1).
+		Foreground(lipgloss.Color("#067D17"))
+	//Foreground(lipgloss.AdaptiveColor{
+	//	Light: "#067D17",
+	//	Dark:  "#82ED7D",
+	//})
+}()
+
+func (m model) headerView() string {
+	wfName := ""
+	stepName := ""
+	runNumber := -1
+	status, meta := "", ""
+	if t := m.tail(); t != nil {
+		yoff := m.lineAt(m.viewport.YOffset)
+		stepName = t.stepNames[len(t.stepNames)-1].stepName
+		for _, name := range t.stepNames {
+			if name.offset <= yoff {
+				stepName = name.stepName
+			}
+		}
+
+		wfName = *t.wfRun.Name
+		runNumber = *t.wfRun.RunNumber
+		status = badge(t)
+		meta = m.metadataView(t)
+	}
+
+	style := titleStyle
+	if m.inactive {
+		style = inactiveTitleStyle
+	}
+
+	title := style.Render(fmt.Sprintf("%s / %s / %s (#%d)", wfName, m.jobLabel(m.tail()), stepName, runNumber))
+	line := strings.Repeat("─", max(0, m.width-lipgloss.Width(title)-lipgloss.Width(status)-1))
+	header := lipgloss.JoinHorizontal(lipgloss.Center, title, line, " ", status)
+
+	if meta != "" {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, meta)
+	}
+
+	if m.concurrent || len(m.tails) > 1 {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, m.tabsView())
+	}
+
+	return header
+}
+
+var (
+	tabStyle       = lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("#808080"))
+	activeTabStyle = tabStyle.Copy().Bold(true).Reverse(true)
+)
+
+// tabsView lists every tailed run in concurrent mode, e.g. "#12 ● #13 ✓", and
+// every job when several match, e.g. "#12 build (ubuntu-latest) ●"
+func (m model) tabsView() string {
+	if len(m.tails) == 0 {
+		return tabStyle.Render("waiting for runs")
+	}
+
+	tabs := make([]string, 0, len(m.tails))
+	for _, t := range m.tails {
+		status, conclusion := t.wfRun.GetStatus(), t.wfRun.GetConclusion()
+		if t.job != nil {
+			status, conclusion = t.job.GetStatus(), t.job.GetConclusion()
+		}
+
+		icon := "●"
+		if status == "completed" {
+			icon = "✓"
+			if conclusion != "success" && conclusion != "skipped" {
+				icon = "✗"
+			}
+		}
+
+		label := fmt.Sprintf("#%d", *t.wfRun.RunNumber)
+		if t.jobName != "" && t.jobName != m.jobs.String() {
+			label += " " + t.jobName
+		}
+
+		style := tabStyle
+		if t == m.shown {
+			style = activeTabStyle
+		}
+
+		tabs = append(tabs, style.Render(fmt.Sprintf("%s %s", label, icon)))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
+
+var infoStyle = func() lipgloss.Style {
+	b := lipgloss.RoundedBorder()
+	b.Left = "┤"
+	return lipgloss.NewStyle().BorderStyle(b).Padding(0, 1)
+}()
+
+var statusStyle = func() lipgloss.Style {
+	b := lipgloss.RoundedBorder()
+	b.Right = "├"
+	return lipgloss.NewStyle().BorderStyle(b).Padding(0, 1)
+}()
+
+func (m model) footerView() string {
+	duration := "-"
+	if t := m.tail(); t != nil {
+		duration = elapsed(t).String()
+		if queued, ran, ok := jobTimes(t); ok {
+			duration = fmt.Sprintf("queued %s · ran %s · %s", queued.Truncate(time.Second), ran.Truncate(time.Second), duration)
+		}
+	}
+
+	info := infoStyle.Render(duration)
+	statuses := []string{}
+	for _, status := range []string{m.confirmStatus(), m.notice, m.followStatus(), m.search.status(), m.filterStatus(), m.annotationsStatus()} {
+		if status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+
+	left := ""
+	if len(statuses) > 0 {
+		left = statusStyle.Render(strings.Join(statuses, " · "))
+	}
+
+	line := strings.Repeat("─", max(0, m.width-lipgloss.Width(left)-lipgloss.Width(info)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, left, line, info)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (m model) View() string {
+	if !m.ready {
+		return "\n  Initializing..."
+	}
+
+	if m.split == splitNone {
+		return m.paneView()
+	}
+
+	return m.splitView()
+}
+
+// paneView renders the viewport with its header and footer, and the sidebar
+// and annotations if they're open.
+func (m model) paneView() string {
+	body := m.viewport.View(func(row int) string {
+		return m.renderLine(m.tail(), m.rows[row])
+	})
+	if m.sidebar.visible {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(), body)
+	}
+	if m.annotations.visible {
+		body = lipgloss.JoinVertical(lipgloss.Left, body, m.annotationsView())
+	}
+	if m.history.visible {
+		body = lipgloss.JoinVertical(lipgloss.Left, body, m.historyView())
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s", m.headerView(), body, m.footerView())
+}
+
+func (m model) waitForActivity() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-m.streams.output:
+			return msg
+		case msg := <-m.streams.tailed:
+			return msg
+		case msg := <-m.streams.updates:
+			return msg
+		case msg := <-m.streams.transitions:
+			return msg
+		case seq := <-m.streams.terminal:
+			return notificationMsg(seq)
+		case err := <-m.streams.errs:
+			return failedMsg{err: err}
+		}
+	}
+}
+
+type tickMsg int
+
+func tick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(_ time.Time) tea.Msg {
+		return tickMsg(0)
+	})
+}
+
+func tailOutput(ctx context.Context, ghl *ghlogs.Ghlogs, s streams, jobs jobPattern, h history, f lineFilter, keys keymap, opts *tailOptions) error {
+	m := model{
+		filter:       f,
+		filterBar:    newFilterBar(),
+		keys:         keys,
+		history:      h,
+		ctx:          ctx,
+		ghl:          ghl,
+		jobs:         jobs,
+		concurrent:   opts.concurrent,
+		gapThreshold: opts.gap,
+		exportFormat: opts.exportFormat,
+
+		streams:   s,
+		memoryCap: opts.memoryCap << 20,
+		search:    newSearch(),
+		pane:      pane{following: true, size: defaultPaneSize},
+	}
+
+	p := tea.NewProgram(m)
+	final, err := p.StartReturningModel()
+	if final, ok := final.(model); ok {
+		closeAll(final.tails)
+	}
+	return errors.WithStack(err)
+}
+
+// closeAll releases the buffers of every run, deleting any temporary files.
+func closeAll(tails []*tailedRun) {
+	for _, t := range tails {
+		t.store.Close()
+	}
+}
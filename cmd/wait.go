@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/aidansteele/ghal"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"net/http"
+	"os"
+	"time"
+)
+
+// The exit codes of `ghal wait`. Any other error also exits with 1.
+const (
+	exitFailure   = 1
+	exitCancelled = 2
+	exitTimedOut  = 3
+	exitAuth      = 4
+)
+
+type waitOptions struct {
+	*globalOptions
+	runId   int64
+	sha     string
+	logs    string
+	timeout time.Duration
+}
+
+func newWaitCmd(g *globalOptions) *cobra.Command {
+	opts := &waitOptions{globalOptions: g}
+
+	cmd := &cobra.Command{
+		Use:   "wait <workflow file>",
+		Short: "Wait for a run to finish, and exit with a code that reflects its conclusion",
+		Long: `Wait for a run to finish, and exit with a code that reflects its conclusion.
+
+The run is chosen by --run, or is the latest run for --sha and/or --branch. If
+there isn't one yet, ghal waits for it to start.
+
+Exit codes:
+  0  the run succeeded
+  1  the run failed (or ghal couldn't wait for it)
+  2  the run was cancelled
+  3  --timeout elapsed first
+  4  GitHub rejected the token`,
+		Example: `  # wait for the deploy of the commit that was just pushed
+  ghal wait deploy.yml --sha $(git rev-parse HEAD)
+
+  # ...and show the logs of its "deploy" job while waiting
+  ghal wait deploy.yml --sha $(git rev-parse HEAD) --logs deploy --timeout 30m`,
+		Args: namedArgs(1, "workflow file"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().Int64Var(&opts.runId, "run", 0, "run ID to wait for")
+	cmd.Flags().StringVar(&opts.sha, "sha", "", "wait for the run of this commit")
+	cmd.Flags().StringVar(&opts.logs, "logs", "", "stream the logs of this job to stderr while waiting")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "give up after this long (default: wait forever)")
+
+	return cmd
+}
+
+func (opts *waitOptions) run(ctx context.Context, workflowFileName string) error {
+	_, err := opts.outputFormat("text")
+	if err != nil {
+		return err
+	}
+
+	jobs, err := parseJobPattern(opts.logs)
+	if err != nil {
+		return err
+	}
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	sess, err := opts.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	run, err := opts.findRun(ctx, sess, workflowFileName)
+	if err != nil {
+		return opts.classify(ctx, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "waiting for run #%d of %s: %s\n", run.GetRunNumber(), run.GetName(), run.GetHTMLURL())
+
+	if opts.logs != "" {
+		go opts.streamLogs(ctx, sess, run, jobs)
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for run.GetStatus() != "completed" {
+		select {
+		case <-ctx.Done():
+			return opts.classify(ctx, ctx.Err())
+		case <-ticker.C:
+			run, _, err = sess.api.Actions.GetWorkflowRunByID(ctx, sess.repo.Owner, sess.repo.Repo, run.GetID())
+			if err != nil {
+				return opts.classify(ctx, errors.WithStack(err))
+			}
+		}
+	}
+
+	conclusion := run.GetConclusion()
+	fmt.Fprintf(os.Stderr, "run #%d of %s concluded: %s (%s)\n", run.GetRunNumber(), run.GetName(), conclusion, runDuration(run))
+
+	switch conclusion {
+	case "success", "skipped", "neutral":
+		return nil
+	case "cancelled":
+		return &exitError{code: exitCancelled, err: errors.Errorf("run #%d was cancelled", run.GetRunNumber())}
+	default:
+		return &exitError{code: exitFailure, err: errors.Errorf("run #%d concluded: %s", run.GetRunNumber(), conclusion)}
+	}
+}
+
+// findRun returns the run to wait for. Unless it was given by ID, it's the
+// latest matching run - or if there isn't one, the next to start (or finish,
+// for a run that does both between polls).
+func (opts *waitOptions) findRun(ctx context.Context, sess *session, workflowFileName string) (*github.WorkflowRun, error) {
+	owner, repo := sess.repo.Owner, sess.repo.Repo
+	if opts.runId != 0 {
+		run, _, err := sess.api.Actions.GetWorkflowRunByID(ctx, owner, repo, opts.runId)
+		return run, errors.WithStack(err)
+	}
+
+	filter := opts.filter()
+	filter.HeadSHA = opts.sha
+
+	recent, err := runs.Recent(ctx, sess.api.Actions, owner, repo, workflowFileName, filter, 30)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recent) > 0 {
+		return recent[0], nil
+	}
+
+	fmt.Fprintf(os.Stderr, "waiting for a run of %s to start\n", workflowFileName)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *github.WorkflowRun)
+	transitions := make(chan runs.Transition)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- runs.Monitor(ctx, sess.api.Actions, ch, transitions, nil, owner, repo, workflowFileName, filter)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-errs:
+			if err == nil {
+				err = ctx.Err()
+			}
+			return nil, err
+		case run := <-ch:
+			return run, nil
+		case t := <-transitions:
+			if t.To == "completed" {
+				return t.Run, nil
+			}
+		}
+	}
+}
+
+// streamLogs prints the logs of the chosen jobs to stderr, the same way as
+// `ghal tail --plain`.
+func (opts *waitOptions) streamLogs(ctx context.Context, sess *session, run *github.WorkflowRun, jobs jobPattern) {
+	ghl := ghlogs.New(sess.api, sess.client, os.Getenv("GITHUB_USER_SESSION"))
+	s := newStreams()
+
+	go plainOutput(ctx, s, jobs, lineFilter{}, os.Stderr, false)
+	s.tailed <- run
+
+	err := ghl.Logs(ctx, s.output, s.updates, runOf(run))
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "couldn't stream logs: %s\n", errors.Cause(err))
+	}
+}
+
+// classify gives err the exit code for a timeout or an auth error, if it is
+// one.
+func (opts *waitOptions) classify(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &exitError{code: exitTimedOut, err: errors.Errorf("timed out after %s", opts.timeout)}
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		switch respErr.Response.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &exitError{code: exitAuth, err: err}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aidansteele/ghal/runs"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+func newWatchCmd(g *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch <workflow file>",
+		Short: "Print status changes of a workflow's runs as they happen",
+		Example: `  ghal watch deploy.yml
+  ghal watch deploy.yml -o json | jq .`,
+		Args: namedArgs(1, "workflow file"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd.Context(), g, args[0])
+		},
+	}
+}
+
+type watchRecord struct {
+	RunId      int64     `json:"run_id"`
+	RunNumber  int       `json:"run_number"`
+	RunAttempt int       `json:"run_attempt"`
+	Branch     string    `json:"branch"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	At         time.Time `json:"at"`
+	URL        string    `json:"url"`
+}
+
+func runWatch(ctx context.Context, g *globalOptions, workflowFileName string) error {
+	format, err := g.outputFormat("text", "json")
+	if err != nil {
+		return err
+	}
+
+	sess, err := g.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	transitionsCh := make(chan runs.Transition)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- runs.Monitor(ctx, sess.api.Actions, nil, transitionsCh, nil, sess.repo.Owner, sess.repo.Repo, workflowFileName, g.filter())
+	}()
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case t := <-transitionsCh:
+			if format == "json" {
+				err = enc.Encode(watchRecord{
+					RunId:      *t.Run.ID,
+					RunNumber:  t.Run.GetRunNumber(),
+					RunAttempt: t.Run.GetRunAttempt(),
+					Branch:     t.Run.GetHeadBranch(),
+					From:       t.From,
+					To:         t.To,
+					Conclusion: t.Conclusion,
+					At:         t.At,
+					URL:        t.Run.GetHTMLURL(),
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				continue
+			}
+
+			from := t.From
+			if from == "" {
+				from = "new"
+			}
+
+			to := t.To
+			if t.Conclusion != "" {
+				to = fmt.Sprintf("%s (%s)", t.To, t.Conclusion)
+			}
+
+			fmt.Printf("%s  #%d  %-12s %s → %s  %s\n", t.At.Local().Format(time.Kitchen), t.Run.GetRunNumber(), t.Run.GetHeadBranch(), from, to, t.Run.GetHTMLURL())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package config loads ghal's configuration: a user config file, which can
+// have a section for each repo, and an optional config file in the repo
+// itself. Later sources override earlier ones.
+package config
+
+import (
+	"github.com/aidansteele/ghal/repoinfo"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Config struct {
+	Workflow string            `yaml:"workflow,omitempty" json:"workflow,omitempty"` // default workflow file
+	Job      string            `yaml:"job,omitempty" json:"job,omitempty"`           // default job name
+	Branch   string            `yaml:"branch,omitempty" json:"branch,omitempty"`     // default branch filter
+	Output   string            `yaml:"output,omitempty" json:"output,omitempty"`     // default output of `tail`: tui, plain or jsonl
+	Aliases  map[string]Alias  `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+	Colors   map[string]string `yaml:"colors,omitempty" json:"colors,omitempty"` // e.g. error: "#FF0000"
+	Keys     map[string]string `yaml:"keys,omitempty" json:"keys,omitempty"`     // action to key, e.g. search: ctrl+f
+
+	// Repos holds overrides for repos, keyed by OWNER/REPO. It's only read
+	// from the user config file.
+	Repos map[string]*Config `yaml:"repos,omitempty" json:"repos,omitempty"`
+}
+
+// Alias is a short name for a job, e.g. `ghal prod`.
+type Alias struct {
+	Workflow string `yaml:"workflow" json:"workflow"`
+	Job      string `yaml:"job,omitempty" json:"job,omitempty"`
+	Branch   string `yaml:"branch,omitempty" json:"branch,omitempty"`
+}
+
+// UserFile returns the path of the user config file: $GHAL_CONFIG if it's
+// set, otherwise ghal/config.yml in the user's config directory (e.g.
+// ~/.config on Linux).
+func UserFile() string {
+	if path := os.Getenv("GHAL_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "ghal", "config.yml")
+}
+
+// RepoFile returns the path of .github/ghal.yml in the repo containing dir,
+// or an empty string if there isn't one.
+func RepoFile(dir string) string {
+	return repoinfo.LocalPath(dir, filepath.Join(".github", "ghal.yml"))
+}
+
+// Load reads and merges the config files that exist, in order: the user
+// file, its section for the repo nwo (if any) and then the repo file. It
+// returns the merged config and the files it was read from.
+func Load(userFile, repoFile, nwo string) (*Config, []string, error) {
+	merged := &Config{}
+	sources := []string{}
+
+	user, err := read(userFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user != nil {
+		sources = append(sources, userFile)
+		merged.merge(user)
+
+		for name, repo := range user.Repos {
+			if nwo != "" && strings.EqualFold(name, nwo) && repo != nil {
+				sources = append(sources, userFile+" (repos."+name+")")
+				merged.merge(repo)
+			}
+		}
+	}
+
+	repo, err := read(repoFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if repo != nil {
+		sources = append(sources, repoFile)
+		merged.merge(repo)
+	}
+
+	return merged, sources, nil
+}
+
+// read parses a config file, or returns nil if it doesn't exist.
+func read(path string) (*Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c := &Config{}
+	err = yaml.Unmarshal(body, c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+
+	return c, nil
+}
+
+// merge overrides c with every field that is set in other.
+func (c *Config) merge(other *Config) {
+	if other.Workflow != "" {
+		c.Workflow = other.Workflow
+	}
+	if other.Job != "" {
+		c.Job = other.Job
+	}
+	if other.Branch != "" {
+		c.Branch = other.Branch
+	}
+	if other.Output != "" {
+		c.Output = other.Output
+	}
+
+	for name, alias := range other.Aliases {
+		if c.Aliases == nil {
+			c.Aliases = map[string]Alias{}
+		}
+		c.Aliases[name] = alias
+	}
+
+	c.Colors = mergeMap(c.Colors, other.Colors)
+	c.Keys = mergeMap(c.Keys, other.Keys)
+}
+
+func mergeMap(dst, src map[string]string) map[string]string {
+	for k, v := range src {
+		if dst == nil {
+			dst = map[string]string{}
+		}
+		dst[k] = v
+	}
+	return dst
+}
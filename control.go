@@ -0,0 +1,43 @@
+package ghlogs
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+)
+
+// Cancel cancels every job of the run that hasn't completed yet.
+func (ghl *Ghlogs) Cancel(ctx context.Context, run Run) error {
+	_, err := ghl.api.Actions.CancelWorkflowRunByID(ctx, run.Owner, run.Repo, run.RunId)
+	return accepted(err)
+}
+
+// Rerun starts a new attempt of the run, with every job re-run.
+func (ghl *Ghlogs) Rerun(ctx context.Context, run Run) error {
+	_, err := ghl.api.Actions.RerunWorkflowByID(ctx, run.Owner, run.Repo, run.RunId)
+	return accepted(err)
+}
+
+// RerunFailed starts a new attempt of the run, with only its failed jobs (and
+// the jobs that depend on them) re-run.
+func (ghl *Ghlogs) RerunFailed(ctx context.Context, run Run) error {
+	// go-github doesn't have this endpoint yet
+	u := fmt.Sprintf("repos/%v/%v/actions/runs/%v/rerun-failed-jobs", run.Owner, run.Repo, run.RunId)
+	req, err := ghl.api.NewRequest("POST", u, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = ghl.api.Do(ctx, req, nil)
+	return accepted(err)
+}
+
+// accepted treats a 202 Accepted response as success. go-github returns it as
+// an error, but it's how GitHub answers requests that it carries out later.
+func accepted(err error) error {
+	if errors.As(err, new(*github.AcceptedError)) {
+		return nil
+	}
+	return errors.WithStack(err)
+}
@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"runtime/pprof"
+	"sort"
 	"sync"
 	"syscall"
 	"time"
@@ -37,21 +38,33 @@ type Run struct {
 	RunId int64
 }
 
+// RunUpdate is sent each time the status of a run and its jobs is refreshed.
+type RunUpdate struct {
+	Run         Run
+	WorkflowRun *github.WorkflowRun
+	Jobs        []JobStatus
+}
+
 type RunOutput struct {
 	Run             Run
 	JobName         string
+	JobId           int64
 	StepName        string
 	StepNumber      int
 	AssumedStepName bool
 	Lines           []string
 }
 
-func (ghl *Ghlogs) Logs(ctx context.Context, outch chan RunOutput, run Run) error {
+// Logs streams the run's output to outch until every job has completed.
+// updatech, which may be nil, receives the status of the run and its jobs
+// whenever they are refreshed.
+func (ghl *Ghlogs) Logs(ctx context.Context, outch chan RunOutput, updatech chan RunUpdate, run Run) error {
 	rs := &runStatus{
 		Run:         run,
 		StepNumbers: map[string]int64{},
-		Statuses:    map[string]*jobStatus{},
+		Statuses:    map[string]*JobStatus{},
 		lock:        &sync.Mutex{},
+		updatech:    updatech,
 	}
 
 	err := ghl.populateRunStatus(ctx, rs)
@@ -140,11 +153,13 @@ func (ghl *Ghlogs) run(ctx context.Context, rs *runStatus, ch chan consoleOutput
 		case ma := <-ch:
 			job, step, certain := rs.step(ma.TimelineRecordId, ma.StepRecordId)
 			jobName := "?"
+			jobId := int64(0)
 			stepName := "?"
 			stepNumber := 0
 
 			if job != nil {
 				jobName = *job.Name
+				jobId = *job.ID
 			}
 
 			if step != nil {
@@ -157,6 +172,7 @@ func (ghl *Ghlogs) run(ctx context.Context, rs *runStatus, ch chan consoleOutput
 			outch <- RunOutput{
 				Run:             rs.Run,
 				JobName:         jobName,
+				JobId:           jobId,
 				StepName:        stepName,
 				StepNumber:      stepNumber,
 				AssumedStepName: !certain,
@@ -268,7 +284,6 @@ func (ghl *Ghlogs) populateRunStatus(ctx context.Context, rs *runStatus) error {
 	}
 
 	rs.lock.Lock()
-	defer rs.lock.Unlock()
 
 	checkRunsById := map[int64]*github.CheckRun{}
 	for _, cr := range suite.CheckRuns {
@@ -279,16 +294,28 @@ func (ghl *Ghlogs) populateRunStatus(ctx context.Context, rs *runStatus) error {
 	for _, job := range jobs.Jobs {
 		job := job
 		cr := checkRunsById[*job.ID]
-		rs.Statuses[*cr.ExternalID] = &jobStatus{
+		rs.Statuses[*cr.ExternalID] = &JobStatus{
 			CheckRun: cr,
 			Job:      job,
 		}
 	}
 
-	return nil
+	update := rs.update()
+	rs.lock.Unlock()
+
+	if rs.updatech == nil {
+		return nil
+	}
+
+	select {
+	case rs.updatech <- update:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-type jobStatus struct {
+type JobStatus struct {
 	CheckRun *github.CheckRun
 	Job      *github.WorkflowJob
 }
@@ -296,11 +323,31 @@ type jobStatus struct {
 type runStatus struct {
 	Run Run
 
-	Statuses    map[string]*jobStatus
+	Statuses    map[string]*JobStatus
 	StepNumbers map[string]int64
 	run         *github.WorkflowRun
 
-	lock sync.Locker
+	lock     sync.Locker
+	updatech chan RunUpdate
+}
+
+// update must be called with rs.lock held.
+func (rs *runStatus) update() RunUpdate {
+	update := RunUpdate{
+		Run:         rs.Run,
+		WorkflowRun: rs.run,
+		Jobs:        make([]JobStatus, 0, len(rs.Statuses)),
+	}
+
+	for _, status := range rs.Statuses {
+		update.Jobs = append(update.Jobs, *status)
+	}
+
+	sort.Slice(update.Jobs, func(i, j int) bool {
+		return *update.Jobs[i].Job.ID < *update.Jobs[j].Job.ID
+	})
+
+	return update
 }
 
 func (rs *runStatus) step(timelineRecordId, stepRecordId string) (*github.WorkflowJob, *github.TaskStep, bool) {
@@ -0,0 +1,112 @@
+package ghlogs
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Jobs returns every job in the run.
+func (ghl *Ghlogs) Jobs(ctx context.Context, run Run) ([]*github.WorkflowJob, error) {
+	opts := &github.ListWorkflowJobsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	all := []*github.WorkflowJob{}
+
+	for {
+		jobs, resp, err := ghl.api.Actions.ListWorkflowJobs(ctx, run.Owner, run.Repo, run.RunId, opts)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		all = append(all, jobs.Jobs...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// FindJob returns the job in the run with the given name.
+func (ghl *Ghlogs) FindJob(ctx context.Context, run Run, jobName string) (*github.WorkflowJob, error) {
+	jobs, err := ghl.Jobs(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, job := range jobs {
+		if *job.Name == jobName {
+			return job, nil
+		}
+		names = append(names, *job.Name)
+	}
+
+	return nil, errors.Errorf("no job named %q in run %d (jobs: %s)", jobName, run.RunId, strings.Join(names, ", "))
+}
+
+// JobLogs returns the complete logs of a job. Unlike Logs, this uses the
+// regular API and so only works once the job has completed.
+func (ghl *Ghlogs) JobLogs(ctx context.Context, run Run, jobId int64) (io.ReadCloser, error) {
+	u, _, err := ghl.api.Actions.GetWorkflowJobLogs(ctx, run.Owner, run.Repo, jobId, true)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	response, err := ghl.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, errors.Errorf("unexpected status code: %s", response.Status)
+	}
+
+	return response.Body, nil
+}
+
+// Annotations returns every annotation on a job's check run, i.e. the errors
+// and warnings that the GitHub UI summarises with their file and line.
+func (ghl *Ghlogs) Annotations(ctx context.Context, run Run, checkRunId int64) ([]*github.CheckRunAnnotation, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	all := []*github.CheckRunAnnotation{}
+
+	for {
+		annotations, resp, err := ghl.api.Checks.ListCheckRunAnnotations(ctx, run.Owner, run.Repo, checkRunId, opts)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		all = append(all, annotations...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Actor returns the login of the user whose action triggered the run (or
+// this attempt of it).
+func (ghl *Ghlogs) Actor(ctx context.Context, run Run) (string, error) {
+	// go-github doesn't have the actor field yet
+	u := fmt.Sprintf("repos/%v/%v/actions/runs/%v", run.Owner, run.Repo, run.RunId)
+	req, err := ghl.api.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var body struct {
+		Actor *github.User `json:"actor"`
+	}
+
+	_, err = ghl.api.Do(ctx, req, &body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return body.Actor.GetLogin(), nil
+}
@@ -0,0 +1,85 @@
+package logfmt
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a log line, as determined by its workflow command
+// marker, e.g. `##[group]`.
+type Kind int
+
+const (
+	KindOutput Kind = iota
+	KindGroup
+	KindEndGroup
+	KindError
+	KindWarning
+	KindNotice
+	KindCommand
+	KindDebug
+)
+
+var markers = map[string]Kind{
+	"##[group]":    KindGroup,
+	"##[endgroup]": KindEndGroup,
+	"##[error]":    KindError,
+	"##[warning]":  KindWarning,
+	"##[notice]":   KindNotice,
+	"##[command]":  KindCommand,
+	"##[debug]":    KindDebug,
+}
+
+var kindNames = map[Kind]string{
+	KindOutput:   "output",
+	KindGroup:    "group",
+	KindEndGroup: "endgroup",
+	KindError:    "error",
+	KindWarning:  "warning",
+	KindNotice:   "notice",
+	KindCommand:  "command",
+	KindDebug:    "debug",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+type Line struct {
+	Raw       string
+	Timestamp time.Time // GitHub's timestamp prefix, or zero if there wasn't one
+	Kind      Kind
+	Text      string // the line without its timestamp and marker
+}
+
+var timestampRegexp = regexp.MustCompile(`^\x{feff}?(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z) ?`)
+
+// Parse splits a raw log line into its timestamp, marker and text.
+func Parse(raw string) Line {
+	l := Line{Raw: raw, Kind: KindOutput, Text: strings.TrimPrefix(raw, "\ufeff")}
+
+	if m := timestampRegexp.FindStringSubmatch(raw); m != nil {
+		if ts, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+			l.Timestamp = ts
+			l.Text = raw[len(m[0]):]
+		}
+	}
+
+	if strings.HasPrefix(l.Text, "##[") {
+		end := strings.IndexByte(l.Text, ']')
+		if kind, ok := markers[l.Text[:end+1]]; end > 0 && ok {
+			l.Kind = kind
+			l.Text = l.Text[end+1:]
+		}
+	}
+
+	return l
+}
+
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// StripANSI removes ANSI escape sequences (colours, cursor movement) from s.
+func StripANSI(s string) string {
+	return ansiRegexp.ReplaceAllString(s, "")
+}
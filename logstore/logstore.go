@@ -0,0 +1,176 @@
+// Package logstore buffers log lines by index. Recent lines are kept in
+// memory, and once they take up more than a configurable number of bytes the
+// oldest of them are moved to a temporary file.
+package logstore
+
+import (
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+)
+
+type Store struct {
+	maxBytes int
+
+	// lines [0, len(offsets)) have been spilled to file. offsets[i] is where
+	// line i starts, and end is where the last one finishes.
+	file    *os.File
+	offsets []int64
+	end     int64
+
+	// lines [len(offsets), Len()) are still in memory
+	mem      []string
+	memBytes int
+}
+
+// New returns a Store that keeps up to maxBytes of lines in memory. A
+// maxBytes of zero or less keeps everything in memory.
+func New(maxBytes int) *Store {
+	return &Store{maxBytes: maxBytes}
+}
+
+// Len returns the number of lines in the store.
+func (s *Store) Len() int {
+	return len(s.offsets) + len(s.mem)
+}
+
+// Append adds lines to the end of the store, spilling older lines to disk if
+// the memory cap has been exceeded.
+func (s *Store) Append(lines ...string) error {
+	for _, line := range lines {
+		s.mem = append(s.mem, line)
+		s.memBytes += len(line)
+	}
+
+	if s.maxBytes <= 0 || s.memBytes <= s.maxBytes {
+		return nil
+	}
+
+	return s.spill()
+}
+
+// spill moves the oldest lines to disk until only half of the memory cap is in
+// use, so that it doesn't happen on every append.
+func (s *Store) spill() error {
+	if s.file == nil {
+		f, err := ioutil.TempFile("", "ghal-*.log")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		s.file = f
+	}
+
+	n, size := 0, 0
+	for n < len(s.mem) && s.memBytes-size > s.maxBytes/2 {
+		size += len(s.mem[n])
+		n++
+	}
+
+	buf := make([]byte, 0, size)
+	for _, line := range s.mem[:n] {
+		s.offsets = append(s.offsets, s.end+int64(len(buf)))
+		buf = append(buf, line...)
+	}
+
+	_, err := s.file.WriteAt(buf, s.end)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.end += int64(len(buf))
+	s.mem = append([]string(nil), s.mem[n:]...)
+	s.memBytes -= size
+	return nil
+}
+
+// Line returns the line at idx.
+func (s *Store) Line(idx int) (string, error) {
+	if idx < 0 || idx >= s.Len() {
+		return "", errors.Errorf("line %d out of range [0, %d)", idx, s.Len())
+	}
+
+	spilled := len(s.offsets)
+	if idx >= spilled {
+		return s.mem[idx-spilled], nil
+	}
+
+	end := s.end
+	if idx+1 < spilled {
+		end = s.offsets[idx+1]
+	}
+
+	buf := make([]byte, end-s.offsets[idx])
+	_, err := s.file.ReadAt(buf, s.offsets[idx])
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(buf), nil
+}
+
+// Lines returns the lines [from, to). Spilled lines are read from disk in a
+// single read.
+func (s *Store) Lines(from, to int) ([]string, error) {
+	if from < 0 || to > s.Len() || from > to {
+		return nil, errors.Errorf("lines [%d, %d) out of range [0, %d)", from, to, s.Len())
+	}
+
+	lines := make([]string, 0, to-from)
+
+	spilled := len(s.offsets)
+	if from < spilled {
+		last := min(to, spilled)
+		end := s.end
+		if last < spilled {
+			end = s.offsets[last]
+		}
+
+		start := s.offsets[from]
+		buf := make([]byte, end-start)
+		_, err := s.file.ReadAt(buf, start)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for idx := from; idx < last; idx++ {
+			lineEnd := end
+			if idx+1 < last {
+				lineEnd = s.offsets[idx+1]
+			}
+			lines = append(lines, string(buf[s.offsets[idx]-start:lineEnd-start]))
+		}
+
+		from = last
+	}
+
+	if from < to {
+		lines = append(lines, s.mem[from-spilled:to-spilled]...)
+	}
+
+	return lines, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Close releases the store, deleting its temporary file if it has one.
+func (s *Store) Close() error {
+	s.mem = nil
+	s.offsets = nil
+	if s.file == nil {
+		return nil
+	}
+
+	name := s.file.Name()
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Remove(name))
+}
@@ -0,0 +1,124 @@
+package logstore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testLines(n int) []string {
+	lines := make([]string, n)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("line %d %s", idx, strings.Repeat("x", idx%7))
+	}
+	lines[n/2] = "" // empty lines take up no room in the file
+	return lines
+}
+
+func TestStoreAppend(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		lines    int
+		spilled  bool
+	}{
+		{name: "no cap", maxBytes: 0, lines: 1000, spilled: false},
+		{name: "below the cap", maxBytes: 1 << 20, lines: 1000, spilled: false},
+		{name: "above the cap", maxBytes: 100, lines: 1000, spilled: true},
+		{name: "line longer than the cap", maxBytes: 4, lines: 10, spilled: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(test.maxBytes)
+			defer s.Close()
+
+			lines := testLines(test.lines)
+			for idx := 0; idx < len(lines); idx += 3 {
+				err := s.Append(lines[idx:min(idx+3, len(lines))]...)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if s.Len() != len(lines) {
+				t.Fatalf("expected %d lines, got %d", len(lines), s.Len())
+			}
+
+			if spilled := len(s.offsets) > 0; spilled != test.spilled {
+				t.Fatalf("expected spilled to be %t, got %t", test.spilled, spilled)
+			}
+
+			if test.maxBytes > 0 && s.memBytes > test.maxBytes {
+				t.Fatalf("expected at most %d bytes in memory, got %d", test.maxBytes, s.memBytes)
+			}
+
+			for idx, expected := range lines {
+				line, err := s.Line(idx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if line != expected {
+					t.Fatalf("expected line %d to be %q, got %q", idx, expected, line)
+				}
+			}
+
+			// ranges within the file, within memory and across both
+			for _, r := range [][2]int{{0, len(lines)}, {0, 1}, {2, 5}, {len(s.offsets) - 1, len(lines)}, {len(lines), len(lines)}} {
+				from, to := max(0, r[0]), r[1]
+				got, err := s.Lines(from, to)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Join(got, "\n") != strings.Join(lines[from:to], "\n") || len(got) != to-from {
+					t.Fatalf("expected lines [%d, %d) to be %q, got %q", from, to, lines[from:to], got)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreOutOfRange(t *testing.T) {
+	s := New(0)
+	defer s.Close()
+
+	if err := s.Append("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Line(2); err == nil {
+		t.Fatalf("expected an error for line 2")
+	}
+
+	if _, err := s.Lines(1, 3); err == nil {
+		t.Fatalf("expected an error for lines [1, 3)")
+	}
+}
+
+func TestStoreCloseRemovesFile(t *testing.T) {
+	s := New(10)
+	if err := s.Append(testLines(100)...); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.file == nil {
+		t.Fatalf("expected lines to have been spilled to a file")
+	}
+
+	name := s.file.Name()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to have been removed, got %v", name, err)
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,33 @@
+package repoinfo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LocalPath returns the path of rel (e.g. ".github/ghal.yml") in the local
+// checkout of the repo containing dir, or an empty string if it doesn't exist.
+// It looks in dir and then each of its parents, up to the root of the repo.
+func LocalPath(dir, rel string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		candidate := filepath.Join(dir, rel)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
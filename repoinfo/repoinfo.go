@@ -1,10 +1,11 @@
 package repoinfo
 
 import (
-	"fmt"
 	"github.com/cli/cli/v2/api"
 	"github.com/cli/cli/v2/pkg/cmd/factory"
+	"github.com/pkg/errors"
 	"os"
+	"strings"
 )
 
 type RepoInfo struct {
@@ -27,29 +28,39 @@ func (r RepoInfo) RepoHost() string {
 	return "github.com"
 }
 
-func Info() *RepoInfo {
+// Info resolves the repo the same way `gh` does, i.e. from the git remotes of
+// the current directory. nwo overrides this when it is of the form OWNER/REPO.
+func Info(nwo string) (*RepoInfo, error) {
 	f := factory.New("1")
-	s := factory.SmartBaseRepoFunc(f)
-	repo, err := s()
-	if err != nil {
-		fmt.Printf("%+v\n", err)
-		panic(err)
-	}
 
-	owner := repo.RepoOwner()
-	name := repo.RepoName()
-	host := repo.RepoHost()
+	owner, name, host := "", "", "github.com"
+	if nwo != "" {
+		parts := strings.Split(nwo, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("expected repo in the format OWNER/REPO, got %q", nwo)
+		}
+
+		owner, name = parts[0], parts[1]
+	} else {
+		s := factory.SmartBaseRepoFunc(f)
+		repo, err := s()
+		if err != nil {
+			return nil, errors.Wrap(err, "determining repo from git remotes (use --repo to set it explicitly)")
+		}
+
+		owner = repo.RepoOwner()
+		name = repo.RepoName()
+		host = repo.RepoHost()
+	}
 
 	cfg, err := f.Config()
 	if err != nil {
-		fmt.Printf("%+v\n", err)
-		panic(err)
+		return nil, errors.WithStack(err)
 	}
 
 	hc, err := f.HttpClient()
 	if err != nil {
-		fmt.Printf("%+v\n", err)
-		panic(err)
+		return nil, errors.WithStack(err)
 	}
 
 	apic := api.NewClientFromHTTP(hc)
@@ -58,8 +69,7 @@ func Info() *RepoInfo {
 	if token == "" {
 		token, err = cfg.Get(host, "oauth_token")
 		if err != nil {
-			fmt.Printf("%+v\n", err)
-			panic(err)
+			return nil, errors.Wrap(err, "no GITHUB_TOKEN set and no gh credentials found")
 		}
 	}
 
@@ -68,5 +78,5 @@ func Info() *RepoInfo {
 		Repo:  name,
 		Token: token,
 		apic:  apic,
-	}
+	}, nil
 }
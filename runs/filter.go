@@ -0,0 +1,32 @@
+package runs
+
+import (
+	"github.com/google/go-github/v43/github"
+	"strings"
+)
+
+// Filter narrows down which runs of a workflow are of interest. Empty fields
+// match everything.
+type Filter struct {
+	Branch  string
+	HeadSHA string // a full or abbreviated commit SHA
+}
+
+func (f Filter) apply(opts *github.ListWorkflowRunsOptions) {
+	opts.Branch = f.Branch
+}
+
+// Matches reports whether run satisfies the filter. Monitor asks the API to
+// filter by branch, but the webhook (and callers with their own runs) can't,
+// and the API can't filter by commit at all.
+func (f Filter) Matches(run *github.WorkflowRun) bool {
+	if f.Branch != "" && run.GetHeadBranch() != f.Branch {
+		return false
+	}
+
+	if f.HeadSHA != "" && !strings.HasPrefix(run.GetHeadSHA(), strings.ToLower(f.HeadSHA)) {
+		return false
+	}
+
+	return true
+}
@@ -2,54 +2,167 @@ package runs
 
 import (
 	"context"
-	"fmt"
 	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
 	"time"
 )
 
 type Lister interface {
 	ListRepositoryWorkflowRuns(ctx context.Context, owner, repo string, opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error)
 	ListWorkflowRunsByFileName(ctx context.Context, owner, repo, filename string, opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error)
+	GetWorkflowRunByID(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRun, *github.Response, error)
 }
 
-func Monitor(ctx context.Context, lister Lister, ch chan *github.WorkflowRun, owner, repo, filename string) {
-	seenRunIds := map[int64]struct{}{}
-	ticker := time.NewTicker(4 * time.Second)
+// createdMargin is how far before the newest run we've seen that we keep
+// looking, as runs don't always show up in the API in creation order.
+const createdMargin = time.Minute
 
-	opts := &github.ListWorkflowRunsOptions{
-		//Actor:       "aidansteele",
-		ListOptions: github.ListOptions{PerPage: 10},
-		//Branch:  "",
-		//Event:   "",
-		//Status:  "",
-		//Created: "",
-	}
+// maxPages bounds how far back a single poll will page.
+const maxPages = 10
+
+// Monitor polls for runs of the workflow. Runs that are not yet completed are
+// sent to ch the first time they are seen, and are then followed until they
+// complete. Every status change is sent to transitions, which may be nil.
+// Runs that have been re-run can be sent on reruns (which may also be nil),
+// as new attempts of old runs don't otherwise show up when polling. It returns
+// the first error from the API, or nil once ctx is done.
+func Monitor(ctx context.Context, lister Lister, ch chan *github.WorkflowRun, transitions chan Transition, reruns chan Rerun, owner, repo, filename string, filter Filter) error {
+	t := newTracker(ch, transitions)
+	baseline := true
+	since := time.Now().Add(-createdMargin)
+	ticker := time.NewTicker(4 * time.Second)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
+		case rerun := <-reruns:
+			t.expect(rerun)
 		case <-ticker.C:
-			wfRuns, _, err := lister.ListWorkflowRunsByFileName(ctx, owner, repo, filename, opts)
-			if err != nil {
-				fmt.Printf("%+v\n", err)
-				panic(err)
+			s, err := listRuns(ctx, lister, owner, repo, filename, filter, baseline, since)
+			if ctx.Err() != nil {
+				return nil
+			} else if err != nil {
+				return err
 			}
 
-			s := wfRuns.WorkflowRuns
+			listed := map[int64]struct{}{}
 			for i := len(s) - 1; i >= 0; i-- {
 				run := s[i]
-				if _, seen := seenRunIds[*run.ID]; !seen {
-					seenRunIds[*run.ID] = struct{}{}
+				if !filter.Matches(run) {
+					continue
+				}
 
-					if *run.Status != "completed" {
-						ch <- run
-					}
+				listed[*run.ID] = struct{}{}
+				t.observe(ctx, run, baseline)
+			}
+
+			// runs we're following might be older than the created filter
+			for _, id := range t.tracked() {
+				if _, ok := listed[id]; ok {
+					continue
+				}
+
+				run, _, err := lister.GetWorkflowRunByID(ctx, owner, repo, id)
+				if ctx.Err() != nil {
+					return nil
+				} else if err != nil {
+					return errors.WithStack(err)
 				}
+
+				t.observe(ctx, run, false)
 			}
+
+			baseline = false
+			if hwm := t.highWaterMark().Add(-createdMargin); hwm.After(since) {
+				since = hwm
+			}
+			t.evict(since)
+		}
+	}
+}
+
+// listRuns returns runs of the workflow, newest first. The first poll only
+// establishes a baseline, so a single page is enough. After that, it pages
+// through every run created since the high-water mark.
+func listRuns(ctx context.Context, lister Lister, owner, repo, filename string, filter Filter, baseline bool, since time.Time) ([]*github.WorkflowRun, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 10},
+	}
+	filter.apply(opts)
+
+	if baseline {
+		wfRuns, _, err := lister.ListWorkflowRunsByFileName(ctx, owner, repo, filename, opts)
+		if err != nil {
+			return nil, errors.WithStack(err)
 		}
+
+		return wfRuns.WorkflowRuns, nil
 	}
 
-	//g.Actions.ListWorkflowRunsByID(ctx, owner, repo, 0, opts)
-	//g.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, "", opts)
+	opts.Created = ">=" + since.UTC().Format(time.RFC3339)
+	opts.PerPage = 50
+
+	var s []*github.WorkflowRun
+	for page := 0; page < maxPages; page++ {
+		wfRuns, resp, err := lister.ListWorkflowRunsByFileName(ctx, owner, repo, filename, opts)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		s = append(s, wfRuns.WorkflowRuns...)
+
+		n := len(wfRuns.WorkflowRuns)
+		if resp.NextPage == 0 || n == 0 || wfRuns.WorkflowRuns[n-1].GetCreatedAt().Before(since) {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return s, nil
+}
+
+// Recent returns up to n of the most recent runs of the workflow, newest first.
+func Recent(ctx context.Context, lister Lister, owner, repo, filename string, filter Filter, n int) ([]*github.WorkflowRun, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		ListOptions: github.ListOptions{PerPage: n},
+	}
+	filter.apply(opts)
+
+	wfRuns, _, err := lister.ListWorkflowRunsByFileName(ctx, owner, repo, filename, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return matching(wfRuns.WorkflowRuns, filter), nil
+}
+
+// RecentInRepo returns up to n of the most recent runs of any workflow in the
+// repo, newest first.
+func RecentInRepo(ctx context.Context, lister Lister, owner, repo string, filter Filter, n int) ([]*github.WorkflowRun, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		ListOptions: github.ListOptions{PerPage: n},
+	}
+	filter.apply(opts)
+
+	wfRuns, _, err := lister.ListRepositoryWorkflowRuns(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return matching(wfRuns.WorkflowRuns, filter), nil
+}
+
+// matching returns the runs that satisfy the filter, for the parts of it that
+// the API can't apply.
+func matching(s []*github.WorkflowRun, filter Filter) []*github.WorkflowRun {
+	var matched []*github.WorkflowRun
+	for _, run := range s {
+		if filter.Matches(run) {
+			matched = append(matched, run)
+		}
+	}
+	return matched
 }
@@ -0,0 +1,208 @@
+package runs
+
+import (
+	"context"
+	"github.com/google/go-github/v43/github"
+	"sync"
+	"time"
+)
+
+// Transition describes a change in a run's status. From is empty the first
+// time a run is observed.
+type Transition struct {
+	Run        *github.WorkflowRun
+	From       string
+	To         string
+	Conclusion string
+	At         time.Time
+}
+
+// Rerun identifies a run that has been re-run, by the attempt that was re-run.
+// Only a later attempt than that is reported as the new one.
+type Rerun struct {
+	RunId   int64
+	Attempt int
+}
+
+// tracker remembers which runs have been seen and follows the runs it has
+// emitted until they complete. It is shared by Monitor and Webhook so that
+// both report runs and transitions identically.
+type tracker struct {
+	ch          chan *github.WorkflowRun
+	transitions chan Transition
+
+	seenRunIds map[int64]time.Time
+	statuses   map[int64]string
+	attempts   map[int64]int
+	pending    map[int64]bool // re-run, but the new attempt hasn't shown up yet
+	lock       sync.Mutex
+}
+
+func newTracker(ch chan *github.WorkflowRun, transitions chan Transition) *tracker {
+	return &tracker{
+		ch:          ch,
+		transitions: transitions,
+		seenRunIds:  map[int64]time.Time{},
+		statuses:    map[int64]string{},
+		attempts:    map[int64]int{},
+		pending:     map[int64]bool{},
+	}
+}
+
+// observe records the latest state of run. Newly-seen incomplete runs are sent
+// to ch, and any status change is sent to transitions (either may be nil). Runs that are already
+// completed the first time they are seen are reported as a transition unless
+// baseline is true, i.e. they predate the start of monitoring. A new attempt
+// of a run that has been seen before is treated like a new run.
+func (t *tracker) observe(ctx context.Context, run *github.WorkflowRun, baseline bool) {
+	id := *run.ID
+	status := *run.Status
+	attempt := run.GetRunAttempt()
+
+	var transition *Transition
+	emit := false
+
+	t.lock.Lock()
+	prev, tracked := t.statuses[id]
+	_, seen := t.seenRunIds[id]
+	pending := t.pending[id]
+
+	switch {
+	case (seen || pending) && attempt > t.attempts[id]:
+		if !seen {
+			t.seenRunIds[id] = run.GetCreatedAt().Time
+		}
+		delete(t.pending, id)
+		t.attempts[id] = attempt
+		transition = newTransition(run, "completed")
+		if status != "completed" {
+			t.statuses[id] = status
+			emit = true
+		} else {
+			delete(t.statuses, id)
+		}
+	case pending:
+		// still waiting for the new attempt
+	case tracked:
+		if prev != status {
+			transition = newTransition(run, prev)
+		}
+
+		if status == "completed" {
+			delete(t.statuses, id)
+		} else {
+			t.statuses[id] = status
+		}
+	case !seen:
+		t.seenRunIds[id] = run.GetCreatedAt().Time
+		t.attempts[id] = attempt
+		if status != "completed" {
+			t.statuses[id] = status
+			transition = newTransition(run, "")
+			emit = true
+		} else if !baseline {
+			transition = newTransition(run, "")
+		}
+	}
+	t.lock.Unlock()
+
+	if emit && t.ch != nil {
+		select {
+		case t.ch <- run:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if transition != nil && t.transitions != nil {
+		select {
+		case t.transitions <- *transition:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func newTransition(run *github.WorkflowRun, from string) *Transition {
+	at := run.GetUpdatedAt().Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	return &Transition{
+		Run:        run,
+		From:       from,
+		To:         *run.Status,
+		Conclusion: run.GetConclusion(),
+		At:         at,
+	}
+}
+
+// expect follows a run that has been re-run, so that its new attempt is
+// reported even though the run was created long ago.
+func (t *tracker) expect(rerun Rerun) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.pending[rerun.RunId] = true
+	if rerun.Attempt > t.attempts[rerun.RunId] {
+		t.attempts[rerun.RunId] = rerun.Attempt
+	}
+}
+
+// done reports whether a run has been observed and is no longer being tracked.
+func (t *tracker) done(runId int64) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	_, seen := t.seenRunIds[runId]
+	_, tracked := t.statuses[runId]
+	return seen && !tracked && !t.pending[runId]
+}
+
+// tracked returns the IDs of runs that have been emitted but not completed,
+// and of runs whose new attempt is awaited.
+func (t *tracker) tracked() []int64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ids := make([]int64, 0, len(t.statuses)+len(t.pending))
+	for id := range t.statuses {
+		ids = append(ids, id)
+	}
+	for id := range t.pending {
+		if _, tracked := t.statuses[id]; !tracked {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// highWaterMark returns the creation time of the newest run seen so far.
+func (t *tracker) highWaterMark() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	hwm := time.Time{}
+	for _, created := range t.seenRunIds {
+		if created.After(hwm) {
+			hwm = created
+		}
+	}
+
+	return hwm
+}
+
+// evict forgets runs created before the given time, unless they are still
+// being tracked. This keeps long sessions from growing without bound.
+func (t *tracker) evict(before time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for id, created := range t.seenRunIds {
+		if _, tracked := t.statuses[id]; !tracked && !t.pending[id] && created.Before(before) {
+			delete(t.seenRunIds, id)
+			delete(t.attempts, id)
+		}
+	}
+}
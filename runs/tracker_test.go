@@ -0,0 +1,184 @@
+package runs
+
+import (
+	"context"
+	"github.com/google/go-github/v43/github"
+	"strings"
+	"testing"
+	"time"
+)
+
+// trackerStep is either an observation of run 1, or (if rerun is true) a
+// re-run of the given attempt of it.
+type trackerStep struct {
+	status   string
+	attempt  int
+	baseline bool
+	rerun    bool
+}
+
+func newTrackedRun(id int64, status string, attempt int, created time.Time) *github.WorkflowRun {
+	run := newTestRun(id, 7)
+	run.Status = github.String(status)
+	run.RunAttempt = github.Int(attempt)
+	run.CreatedAt = &github.Timestamp{Time: created}
+	return run
+}
+
+func drain(ch chan Transition) []string {
+	got := []string{}
+	for len(ch) > 0 {
+		tr := <-ch
+		got = append(got, tr.From+"->"+tr.To)
+	}
+	return got
+}
+
+func TestTrackerObserve(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       []trackerStep
+		emitted     int
+		transitions []string
+		done        bool
+	}{
+		{
+			name: "new run until it completes",
+			steps: []trackerStep{
+				{status: "queued", attempt: 1},
+				{status: "in_progress", attempt: 1},
+				{status: "in_progress", attempt: 1},
+				{status: "completed", attempt: 1},
+			},
+			emitted:     1,
+			transitions: []string{"->queued", "queued->in_progress", "in_progress->completed"},
+			done:        true,
+		},
+		{
+			name: "completed before monitoring started",
+			steps: []trackerStep{
+				{status: "completed", attempt: 1, baseline: true},
+			},
+			emitted:     0,
+			transitions: []string{},
+			done:        true,
+		},
+		{
+			name: "completed when first seen",
+			steps: []trackerStep{
+				{status: "completed", attempt: 1},
+				{status: "completed", attempt: 1},
+			},
+			emitted:     0,
+			transitions: []string{"->completed"},
+			done:        true,
+		},
+		{
+			name: "new attempt of a completed run",
+			steps: []trackerStep{
+				{status: "in_progress", attempt: 1},
+				{status: "completed", attempt: 1},
+				{status: "queued", attempt: 2},
+				{status: "completed", attempt: 2},
+			},
+			emitted:     2,
+			transitions: []string{"->in_progress", "in_progress->completed", "completed->queued", "queued->completed"},
+			done:        true,
+		},
+		{
+			name: "re-run that hasn't started yet",
+			steps: []trackerStep{
+				{status: "completed", attempt: 1, baseline: true},
+				{attempt: 1, rerun: true},
+				{status: "completed", attempt: 1},
+			},
+			emitted:     0,
+			transitions: []string{},
+			done:        false,
+		},
+		{
+			name: "re-run of a run from before monitoring started",
+			steps: []trackerStep{
+				{attempt: 1, rerun: true},
+				{status: "completed", attempt: 1},
+				{status: "queued", attempt: 2},
+			},
+			emitted:     1,
+			transitions: []string{"completed->queued"},
+			done:        false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ch := make(chan *github.WorkflowRun, 10)
+			transitions := make(chan Transition, 10)
+			tr := newTracker(ch, transitions)
+
+			for _, step := range test.steps {
+				if step.rerun {
+					tr.expect(Rerun{RunId: 1, Attempt: step.attempt})
+					continue
+				}
+				tr.observe(context.Background(), newTrackedRun(1, step.status, step.attempt, time.Now()), step.baseline)
+			}
+
+			if len(ch) != test.emitted {
+				t.Fatalf("expected %d runs, got %d", test.emitted, len(ch))
+			}
+
+			got := drain(transitions)
+			if strings.Join(got, ", ") != strings.Join(test.transitions, ", ") {
+				t.Fatalf("expected transitions %v, got %v", test.transitions, got)
+			}
+
+			if tr.done(1) != test.done {
+				t.Fatalf("expected done to be %t, got %t", test.done, tr.done(1))
+			}
+		})
+	}
+}
+
+func TestTrackerEvict(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	recent := time.Now()
+
+	transitions := make(chan Transition, 10)
+	tr := newTracker(nil, transitions)
+	ctx := context.Background()
+
+	tr.observe(ctx, newTrackedRun(1, "completed", 1, old), true)
+	tr.observe(ctx, newTrackedRun(2, "in_progress", 1, old), true)
+	tr.observe(ctx, newTrackedRun(3, "completed", 1, old), true)
+	tr.expect(Rerun{RunId: 3, Attempt: 1})
+	tr.observe(ctx, newTrackedRun(4, "completed", 1, recent), true)
+	drain(transitions)
+
+	tr.evict(recent.Add(-time.Minute))
+
+	if tr.done(1) {
+		t.Fatalf("expected run 1 to be forgotten")
+	}
+
+	if _, seen := tr.seenRunIds[2]; !seen {
+		t.Fatalf("expected run 2 to be kept while it is in progress")
+	}
+
+	if _, seen := tr.seenRunIds[3]; !seen {
+		t.Fatalf("expected run 3 to be kept while its new attempt is awaited")
+	}
+
+	if !tr.done(4) {
+		t.Fatalf("expected run 4 to be kept")
+	}
+
+	if hwm := tr.highWaterMark(); !hwm.Equal(recent) {
+		t.Fatalf("expected high water mark %s, got %s", recent, hwm)
+	}
+
+	// a forgotten run is treated as new if it shows up again
+	tr.observe(ctx, newTrackedRun(1, "completed", 1, old), false)
+	if got := drain(transitions); len(got) != 1 || got[0] != "->completed" {
+		t.Fatalf("expected run 1 to be reported again, got %v", got)
+	}
+}
@@ -0,0 +1,232 @@
+package runs
+
+import (
+	"context"
+	"github.com/google/go-github/v43/github"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webhookRetention is how long the webhook remembers runs it has seen. Unlike
+// Monitor, there's no created filter to bound which runs show up.
+const webhookRetention = 24 * time.Hour
+
+type Getter interface {
+	GetWorkflowRunByID(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRun, *github.Response, error)
+	GetWorkflowByFileName(ctx context.Context, owner, repo, workflowFileName string) (*github.Workflow, *github.Response, error)
+}
+
+// Webhook receives `workflow_run` and `workflow_job` webhook deliveries (e.g.
+// forwarded by a smee.io relay or a tunnel) and sends newly-seen, incomplete
+// runs of the given workflow to ch - the same as Monitor does by polling.
+type Webhook struct {
+	getter   Getter
+	tracker  *tracker
+	secret   []byte
+	owner    string
+	repo     string
+	filename string
+	filter   Filter
+
+	workflowId   int64
+	runWorkflows map[int64]runWorkflow // so that jobs of other workflows' runs can be ignored
+	lock         sync.Mutex
+}
+
+type runWorkflow struct {
+	workflowId int64
+	seen       time.Time
+}
+
+func NewWebhook(getter Getter, ch chan *github.WorkflowRun, transitions chan Transition, secret []byte, owner, repo, filename string, filter Filter) *Webhook {
+	return &Webhook{
+		getter:   getter,
+		tracker:  newTracker(ch, transitions),
+		secret:   secret,
+		owner:    owner,
+		repo:     repo,
+		filename: filename,
+		filter:   filter,
+
+		runWorkflows: map[int64]runWorkflow{},
+	}
+}
+
+// Serve serves the webhook on l until ctx is cancelled. The listener is the
+// caller's so that a bad address is reported before anything else starts.
+func (wh *Webhook) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           wh,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	err := srv.Serve(l)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return errors.WithStack(err)
+}
+
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := github.ValidatePayload(r, wh.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	var run *github.WorkflowRun
+	switch event := event.(type) {
+	case *github.WorkflowRunEvent:
+		run = event.WorkflowRun
+		wh.remember(run)
+	case *github.WorkflowJobEvent:
+		run, err = wh.runForJob(ctx, event.WorkflowJob)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if run == nil || !wh.matchesRepo(run) || !wh.filter.Matches(run) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	matches, err := wh.matchesWorkflow(ctx, run)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	wh.evict(time.Now().Add(-webhookRetention))
+	if !matches {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	wh.tracker.observe(ctx, run, false)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (wh *Webhook) runForJob(ctx context.Context, job *github.WorkflowJob) (*github.WorkflowRun, error) {
+	if job == nil || job.RunID == nil || wh.tracker.done(*job.RunID) {
+		return nil, nil
+	}
+
+	// every job of every workflow in the repo is delivered, so only look up
+	// runs that aren't already known to be of another workflow
+	workflowId, err := wh.workflowID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wh.lock.Lock()
+	known, ok := wh.runWorkflows[*job.RunID]
+	wh.lock.Unlock()
+
+	if ok && known.workflowId != workflowId {
+		return nil, nil
+	}
+
+	run, _, err := wh.getter.GetWorkflowRunByID(ctx, wh.owner, wh.repo, *job.RunID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	wh.remember(run)
+	return run, nil
+}
+
+// remember records which workflow a run belongs to.
+func (wh *Webhook) remember(run *github.WorkflowRun) {
+	if run == nil || run.ID == nil {
+		return
+	}
+
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	wh.runWorkflows[*run.ID] = runWorkflow{workflowId: run.GetWorkflowID(), seen: time.Now()}
+}
+
+// evict forgets runs that haven't been heard of since the given time.
+func (wh *Webhook) evict(before time.Time) {
+	wh.tracker.evict(before)
+
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	for id, rw := range wh.runWorkflows {
+		if rw.seen.Before(before) {
+			delete(wh.runWorkflows, id)
+		}
+	}
+}
+
+func (wh *Webhook) matchesRepo(run *github.WorkflowRun) bool {
+	if run.Repository == nil {
+		return false
+	}
+
+	return strings.EqualFold(run.Repository.GetOwner().GetLogin(), wh.owner) &&
+		strings.EqualFold(run.Repository.GetName(), wh.repo)
+}
+
+func (wh *Webhook) matchesWorkflow(ctx context.Context, run *github.WorkflowRun) (bool, error) {
+	workflowId, err := wh.workflowID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return run.GetWorkflowID() == workflowId, nil
+}
+
+// workflowID looks up the ID of the workflow file the first time it's needed.
+func (wh *Webhook) workflowID(ctx context.Context) (int64, error) {
+	wh.lock.Lock()
+	workflowId := wh.workflowId
+	wh.lock.Unlock()
+
+	if workflowId != 0 {
+		return workflowId, nil
+	}
+
+	wf, _, err := wh.getter.GetWorkflowByFileName(ctx, wh.owner, wh.repo, wh.filename)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	wh.lock.Lock()
+	wh.workflowId = *wf.ID
+	wh.lock.Unlock()
+
+	return *wf.ID, nil
+}
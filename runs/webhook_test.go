@@ -0,0 +1,167 @@
+package runs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/google/go-github/v43/github"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+var testSecret = []byte("s3cret")
+
+type fakeGetter struct {
+	runs    map[int64]*github.WorkflowRun
+	lookups []int64
+	lock    sync.Mutex
+}
+
+func (f *fakeGetter) GetWorkflowRunByID(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRun, *github.Response, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.lookups = append(f.lookups, runID)
+	return f.runs[runID], nil, nil
+}
+
+func (f *fakeGetter) GetWorkflowByFileName(ctx context.Context, owner, repo, workflowFileName string) (*github.Workflow, *github.Response, error) {
+	return &github.Workflow{ID: github.Int64(7)}, nil, nil
+}
+
+func newTestRun(id, workflowId int64) *github.WorkflowRun {
+	return &github.WorkflowRun{
+		ID:         github.Int64(id),
+		WorkflowID: github.Int64(workflowId),
+		Status:     github.String("in_progress"),
+		RunAttempt: github.Int(1),
+		Repository: &github.Repository{
+			Name:  github.String("ghal"),
+			Owner: &github.User{Login: github.String("aidansteele")},
+		},
+	}
+}
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile("testdata/" + name + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func deliver(t *testing.T, srv *httptest.Server, event string, body, secret []byte) int {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func newTestWebhook(getter Getter) (*Webhook, chan *github.WorkflowRun, *httptest.Server) {
+	ch := make(chan *github.WorkflowRun, 10)
+	wh := NewWebhook(getter, ch, nil, testSecret, "aidansteele", "ghal", "ci.yml", Filter{})
+	return wh, ch, httptest.NewServer(wh)
+}
+
+func TestWebhookRejectsBadSignature(t *testing.T) {
+	_, ch, srv := newTestWebhook(&fakeGetter{})
+	defer srv.Close()
+
+	status := deliver(t, srv, "workflow_run", fixture(t, "workflow_run"), []byte("wrong"))
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, status)
+	}
+
+	if len(ch) != 0 {
+		t.Fatalf("expected no runs, got %d", len(ch))
+	}
+}
+
+func TestWebhookWorkflowRun(t *testing.T) {
+	getter := &fakeGetter{}
+	_, ch, srv := newTestWebhook(getter)
+	defer srv.Close()
+
+	status := deliver(t, srv, "workflow_run", fixture(t, "workflow_run"), testSecret)
+	if status != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, status)
+	}
+
+	if len(ch) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(ch))
+	}
+
+	if run := <-ch; run.GetID() != 100 {
+		t.Fatalf("expected run 100, got %d", run.GetID())
+	}
+
+	if len(getter.lookups) != 0 {
+		t.Fatalf("expected no run lookups, got %v", getter.lookups)
+	}
+}
+
+func TestWebhookWorkflowJob(t *testing.T) {
+	getter := &fakeGetter{runs: map[int64]*github.WorkflowRun{200: newTestRun(200, 7)}}
+	_, ch, srv := newTestWebhook(getter)
+	defer srv.Close()
+
+	status := deliver(t, srv, "workflow_job", fixture(t, "workflow_job"), testSecret)
+	if status != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, status)
+	}
+
+	if len(ch) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(ch))
+	}
+
+	if run := <-ch; run.GetID() != 200 {
+		t.Fatalf("expected run 200, got %d", run.GetID())
+	}
+}
+
+func TestWebhookSkipsJobsOfOtherWorkflows(t *testing.T) {
+	getter := &fakeGetter{runs: map[int64]*github.WorkflowRun{200: newTestRun(200, 8)}}
+	_, ch, srv := newTestWebhook(getter)
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		status := deliver(t, srv, "workflow_job", fixture(t, "workflow_job"), testSecret)
+		if status != http.StatusNoContent {
+			t.Fatalf("expected %d, got %d", http.StatusNoContent, status)
+		}
+	}
+
+	if len(ch) != 0 {
+		t.Fatalf("expected no runs, got %d", len(ch))
+	}
+
+	// only the first delivery needs to look up which workflow the run is of
+	if len(getter.lookups) != 1 {
+		t.Fatalf("expected 1 run lookup, got %v", getter.lookups)
+	}
+}
@@ -0,0 +1,123 @@
+package workflows
+
+import (
+	"github.com/aidansteele/ghal/repoinfo"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Workflow struct {
+	FileName string // e.g. "ci.yml", as accepted by the API
+	Name     string
+	Jobs     []Job
+}
+
+type Job struct {
+	Id   string
+	Name string // the `name:` if set, otherwise the same as Id
+}
+
+// Dir returns the .github/workflows directory of the repo containing dir, or
+// an empty string if there isn't one.
+func Dir(dir string) string {
+	wfDir := repoinfo.LocalPath(dir, filepath.Join(".github", "workflows"))
+	if fi, err := os.Stat(wfDir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return wfDir
+}
+
+// Local parses every workflow file in the .github/workflows directory of the
+// repo containing dir, sorted by file name. Files that can't be read or parsed
+// are left out, and their errors returned by file name in broken, so that one
+// bad file doesn't stop the others being used.
+func Local(dir string) (wfs []Workflow, broken map[string]error, err error) {
+	broken = map[string]error{}
+
+	wfDir := Dir(dir)
+	if wfDir == "" {
+		return nil, broken, nil
+	}
+
+	entries, err := os.ReadDir(wfDir)
+	if err != nil {
+		return nil, broken, errors.WithStack(err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		body, err := ioutil.ReadFile(filepath.Join(wfDir, name))
+		if err != nil {
+			broken[name] = errors.WithStack(err)
+			continue
+		}
+
+		wf, err := Parse(name, body)
+		if err != nil {
+			broken[name] = err
+			continue
+		}
+
+		wfs = append(wfs, wf)
+	}
+
+	sort.Slice(wfs, func(i, j int) bool {
+		return wfs[i].FileName < wfs[j].FileName
+	})
+
+	return wfs, broken, nil
+}
+
+type workflowFile struct {
+	Name string    `yaml:"name"`
+	Jobs yaml.Node `yaml:"jobs"`
+}
+
+type jobDefinition struct {
+	Name string `yaml:"name"`
+}
+
+// Parse reads the name and jobs of a workflow, keeping the jobs in the order
+// they're defined in the file.
+func Parse(fileName string, body []byte) (Workflow, error) {
+	wf := Workflow{FileName: fileName}
+
+	file := workflowFile{}
+	err := yaml.Unmarshal(body, &file)
+	if err != nil {
+		return wf, errors.Wrapf(err, "parsing workflow %s", fileName)
+	}
+
+	wf.Name = file.Name
+	if wf.Name == "" {
+		wf.Name = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+
+	content := file.Jobs.Content
+	for i := 0; i+1 < len(content); i += 2 {
+		job := Job{Id: content[i].Value}
+
+		def := jobDefinition{}
+		if err := content[i+1].Decode(&def); err == nil {
+			job.Name = def.Name
+		}
+
+		if job.Name == "" {
+			job.Name = job.Id
+		}
+
+		wf.Jobs = append(wf.Jobs, job)
+	}
+
+	return wf, nil
+}